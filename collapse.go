@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CollapseWriter collapses consecutive repeated entries into one, which is
+// written with the number of repeats once a different entry arrives, the
+// input goes idle, or the writer is closed. Continuation lines of the
+// repeats are dropped.
+type CollapseWriter struct {
+	Next EntryWriter
+	// Ignore lists glob patterns of volatile fields, such as ids and
+	// durations, that may differ between repeated entries.
+	Ignore []string
+	// Idle is how long to wait for another repeat before writing the
+	// collapsed entry. Zero waits until the next entry or Close.
+	Idle time.Duration
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	pending  []*Entry
+	key      string
+	dropping bool
+	// err is the error of writing the entry when the input went idle, which
+	// is returned by the next call instead.
+	err error
+}
+
+func (w *CollapseWriter) WriteEntry(e *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+
+	if e.Continuation && len(w.pending) > 0 {
+		if !w.dropping {
+			w.pending = append(w.pending, e)
+		}
+		return nil
+	}
+
+	key := w.entryKey(e)
+	if len(w.pending) > 0 && key == w.key {
+		head := w.pending[0]
+		if head.Repeats == 0 {
+			head.Repeats = 1
+		}
+		head.Repeats++
+		if !e.Time.IsZero() && !head.Time.IsZero() {
+			head.RepeatSpan = e.Time.Sub(head.Time)
+		}
+		w.dropping = true
+		w.resetTimer()
+		return nil
+	}
+
+	err := w.flush()
+	w.pending = []*Entry{e}
+	w.key = key
+	w.dropping = false
+	w.resetTimer()
+	return err
+}
+
+func (w *CollapseWriter) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	err := w.err
+	if err == nil {
+		err = w.flush()
+	}
+	w.mu.Unlock()
+	if errors.Is(err, ErrStopReading) {
+		// Not a failure, only that the output is done with the entries.
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return w.Next.Close()
+}
+
+func (w *CollapseWriter) resetTimer() {
+	if w.Idle <= 0 {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.Idle, w.onIdle)
+		return
+	}
+	w.timer.Reset(w.Idle)
+}
+
+func (w *CollapseWriter) onIdle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.flush()
+	}
+}
+
+// flush writes the pending entry with its continuation lines. Must be
+// called with the mutex held.
+func (w *CollapseWriter) flush() error {
+	pending := w.pending
+	w.pending = nil
+	for _, e := range pending {
+		if err := w.Next.WriteEntry(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryKey returns a string that is equal for entries that are repeats of
+// each other.
+func (w *CollapseWriter) entryKey(e *Entry) string {
+	fields := make(Object, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		if !matchAnyGlob(w.Ignore, field.Key) {
+			fields = append(fields, field)
+		}
+	}
+	return e.Level.String() + "\x00" + e.Caller + "\x00" + e.Message + "\x00" + fieldValueString(fields)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCollapseWriter(t *testing.T) {
+	rec := &entryRecorder{}
+	w := &CollapseWriter{Next: rec, Ignore: []string{"id"}}
+	base := time.Date(2022, 9, 20, 17, 0, 0, 0, time.UTC)
+
+	entries := []*Entry{
+		{Level: zerolog.ErrorLevel, Time: base, Message: "refused", Fields: []Field{{Key: "id", Value: int64(1)}}},
+		{Level: zerolog.NoLevel, Message: "  at main.go:3", Continuation: true},
+		{Level: zerolog.ErrorLevel, Time: base.Add(5 * time.Second), Message: "refused", Fields: []Field{{Key: "id", Value: int64(2)}}},
+		{Level: zerolog.NoLevel, Message: "  at main.go:3", Continuation: true},
+		{Level: zerolog.ErrorLevel, Time: base.Add(12 * time.Second), Message: "refused", Fields: []Field{{Key: "id", Value: int64(3)}}},
+		{Level: zerolog.InfoLevel, Time: base.Add(13 * time.Second), Message: "started"},
+		{Level: zerolog.InfoLevel, Time: base.Add(14 * time.Second), Message: "started", Fields: []Field{{Key: "port", Value: int64(80)}}},
+	}
+	for _, e := range entries {
+		w.WriteEntry(e)
+	}
+	w.Close()
+
+	assertMessages(t, []string{"refused", "  at main.go:3", "started", "started"}, rec.messages())
+	assertEqualString(t, "(repeated 3× over 12s)", repeatsString(rec.entries[0]), "repeats")
+	if rec.entries[2].Repeats != 0 {
+		t.Errorf("want no repeats for differing fields, got %d", rec.entries[2].Repeats)
+	}
+}
+
+// stoppingWriter stops reading after the first entry.
+type stoppingWriter struct {
+	entryRecorder
+}
+
+func (w *stoppingWriter) WriteEntry(e *Entry) error {
+	if len(w.entries) > 0 {
+		return ErrStopReading
+	}
+	return w.entryRecorder.WriteEntry(e)
+}
+
+func TestCollapseWriterIdleError(t *testing.T) {
+	rec := &stoppingWriter{}
+	w := &CollapseWriter{Next: rec, Idle: 10 * time.Millisecond}
+
+	w.WriteEntry(&Entry{Level: zerolog.InfoLevel, Message: "first"})
+	w.WriteEntry(&Entry{Level: zerolog.InfoLevel, Message: "second"})
+	time.Sleep(100 * time.Millisecond)
+
+	if err := w.WriteEntry(&Entry{Level: zerolog.InfoLevel, Message: "third"}); err != ErrStopReading {
+		t.Errorf("want ErrStopReading from the idle write, got %v", err)
+	}
+	w.mu.Lock()
+	assertMessages(t, []string{"first"}, rec.messages())
+	w.mu.Unlock()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jilleJr/relog/pkg/config"
+)
+
+// FieldColorRule colors the value of a field when its value matches a
+// condition, such as "status>=500" or "user=admin".
+type FieldColorRule struct {
+	Key     string
+	Op      string
+	Operand string
+	Regex   *regexp.Regexp
+	Color   *color.Color
+}
+
+var fieldColorRuleRegex = regexp.MustCompile(`^\s*([^\s<>=!~]+)\s*(>=|<=|!=|==|=~|=|>|<)\s*(.*?)\s*$`)
+
+func ParseFieldColorRule(rule string, c *color.Color) (FieldColorRule, error) {
+	groups := fieldColorRuleRegex.FindStringSubmatch(rule)
+	if groups == nil {
+		return FieldColorRule{}, fmt.Errorf("invalid field color rule %q, expected format like status>=500", rule)
+	}
+	r := FieldColorRule{
+		Key:     groups[1],
+		Op:      groups[2],
+		Operand: strings.Trim(groups[3], `"`),
+		Color:   c,
+	}
+	if r.Op == "==" {
+		r.Op = "="
+	}
+	if r.Op == "=~" {
+		regex, err := regexp.Compile(r.Operand)
+		if err != nil {
+			return FieldColorRule{}, fmt.Errorf("field color rule %q: %w", rule, err)
+		}
+		r.Regex = regex
+	}
+	return r, nil
+}
+
+func NewFieldColorRules(fieldColors []config.FieldColor) ([]FieldColorRule, error) {
+	rules := make([]FieldColorRule, 0, len(fieldColors))
+	for _, fc := range fieldColors {
+		c, err := ParseColor(fc.Color)
+		if err != nil {
+			return nil, fmt.Errorf("field color rule %q: %w", fc.Rule, err)
+		}
+		rule, err := ParseFieldColorRule(fc.Rule, c)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r FieldColorRule) Match(key string, value any) bool {
+	if key != r.Key {
+		return false
+	}
+	if r.Regex != nil {
+		return r.Regex.MatchString(fieldValueString(value))
+	}
+	return compareFieldValue(value, r.Op, r.Operand)
+}
+
+// compareFieldValue compares a field value with an operand from the user.
+// Numbers and durations are compared by their magnitude, while anything
+// else is compared as strings.
+func compareFieldValue(value any, op, operand string) bool {
+	valueStr := fieldValueString(value)
+	if a, ok := fieldValueFloat(value); ok {
+		if b, err := strconv.ParseFloat(operand, 64); err == nil {
+			return compareOrdered(a, b, op)
+		}
+	}
+	if b, err := time.ParseDuration(operand); err == nil {
+		if a, err := time.ParseDuration(valueStr); err == nil {
+			return compareOrdered(a, b, op)
+		}
+	}
+	return compareOrdered(valueStr, operand, op)
+}
+
+func fieldValueFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func compareOrdered[T int64 | float64 | time.Duration | string](a, b T, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	default:
+		return false
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestFieldColorRuleMatch(t *testing.T) {
+	tests := []struct {
+		rule  string
+		key   string
+		value any
+		want  bool
+	}{
+		{rule: "status>=500", key: "status", value: int64(503), want: true},
+		{rule: "status>=500", key: "status", value: int64(200), want: false},
+		{rule: "status>=500", key: "code", value: int64(503), want: false},
+		{rule: "duration>1s", key: "duration", value: "2.103117406s", want: true},
+		{rule: "duration>1s", key: "duration", value: "93.435249ms", want: false},
+		{rule: "user=admin", key: "user", value: "admin", want: true},
+		{rule: `user != "admin"`, key: "user", value: "admin", want: false},
+		{rule: "path=~^/api", key: "path", value: "/api/v1", want: true},
+	}
+	for _, tc := range tests {
+		rule, err := ParseFieldColorRule(tc.rule, nil)
+		if err != nil {
+			t.Fatalf("parse %q: %s", tc.rule, err)
+		}
+		if got := rule.Match(tc.key, tc.value); got != tc.want {
+			t.Errorf("rule %q with %s=%v: want %t, got %t", tc.rule, tc.key, tc.value, tc.want, got)
+		}
+	}
+}
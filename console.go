@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rs/zerolog"
+)
+
+// ConsoleWriter writes entries in a colorized, human-friendly format,
+// modeled after [zerolog.ConsoleWriter].
+type ConsoleWriter struct {
+	Out             io.Writer
+	TimeFormat      string
+	Theme           Theme
+	FieldColorRules []FieldColorRule
+	Nested          NestedOptions
+	FieldOrder      FieldOrder
+	FieldSelection  FieldSelection
+	// Columns holds the fields whose values are padded to a stable width, so
+	// they line up across entries. The "caller" key is used for the caller.
+	Columns map[string]*PaddedString
+	Layout  Layout
+	// ShowRaw writes the raw input lines below each entry.
+	ShowRaw bool
+	// RawOnUnparsed writes the raw input lines instead of entries that no
+	// processor recognized.
+	RawOnUnparsed bool
+	// Links turns callers and URLs into hyperlinks. Nil disables them.
+	Links *Hyperlinker
+	// RuntimeFrames writes the runtime frames of goroutine dumps, instead
+	// of collapsing them into a count.
+	RuntimeFrames bool
+}
+
+// NewColumns creates the padding for each of the column keys, where the
+// widths adapt to the last window number of entries.
+func NewColumns(keys []string, window int) map[string]*PaddedString {
+	columns := make(map[string]*PaddedString, len(keys))
+	for _, key := range keys {
+		columns[key] = NewPaddedString(window)
+	}
+	return columns
+}
+
+func (w *ConsoleWriter) pad(key, value string) string {
+	if p, ok := w.Columns[key]; ok {
+		return p.Next(value)
+	}
+	return value
+}
+
+func (w *ConsoleWriter) WriteEntry(e *Entry) error {
+	var buf bytes.Buffer
+	if e.Unparsed && w.RawOnUnparsed {
+		for _, line := range e.Raw {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		_, err := buf.WriteTo(w.Out)
+		return err
+	}
+	w.writeHeader(&buf, e)
+	if e.Message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(w.formatMessage(e.Message, e.Properties, e.Highlight))
+	}
+	if e.Repeats > 1 {
+		buf.WriteByte(' ')
+		buf.WriteString(w.Theme.Dim.Sprint(repeatsString(e)))
+	}
+	fields, nested := w.splitFields(e.Fields, w.Nested.Mode)
+	w.Layout.writeFields(&buf, w.formatFields(fields, e.Highlight))
+	buf.WriteByte('\n')
+	w.writeTree(&buf, nested, "\t", 0)
+	if e.Dump != nil {
+		w.writeDump(&buf, e.Dump)
+	}
+	if w.ShowRaw {
+		for _, line := range e.Raw {
+			buf.WriteString(w.Theme.Dim.Sprint(line))
+			buf.WriteByte('\n')
+		}
+	}
+	_, err := buf.WriteTo(w.Out)
+	return err
+}
+
+// WriteSeparator writes a line between groups of entries that are not next
+// to each other in the input.
+func (w *ConsoleWriter) WriteSeparator() error {
+	_, err := io.WriteString(w.Out, w.Theme.Dim.Sprint("--")+"\n")
+	return err
+}
+
+func (w *ConsoleWriter) Close() error {
+	return nil
+}
+
+// writeHeader writes the timestamp, level, and caller.
+func (w *ConsoleWriter) writeHeader(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString(w.Theme.Timestamp.Sprint(e.Time.Local().Format(w.TimeFormat)))
+	buf.WriteByte(' ')
+	buf.WriteString(w.Theme.LevelColor(e.Level).Sprint(levelShortName(e.Level)))
+	if e.Caller != "" {
+		buf.WriteByte(' ')
+		caller := w.pad(zerolog.CallerFieldName, relativeCaller(e.Caller))
+		if w.Links != nil {
+			caller = w.Links.LinkCaller(e.Caller, caller)
+		}
+		buf.WriteString(w.Theme.Caller.Sprint(caller))
+		buf.WriteString(w.Theme.Arrow.Sprint(" >"))
+	}
+}
+
+// splitFields selects and orders the fields, and splits them into the ones to print
+// inline and the nested ones to print as a tree.
+func (w *ConsoleWriter) splitFields(fields []Field, mode NestedMode) (inline, nested []Field) {
+	fields = w.FieldSelection.Apply(fields)
+	switch mode {
+	case NestedFlatten:
+		inline = w.Nested.flatten(fields)
+	case NestedTree:
+		inline, nested = splitNested(fields)
+	default:
+		inline = fields
+	}
+	return w.FieldOrder.Apply(inline), w.FieldOrder.Apply(nested)
+}
+
+func (w *ConsoleWriter) linkURLs(s string) string {
+	if w.Links == nil {
+		return s
+	}
+	return w.Links.LinkURLs(s)
+}
+
+// colorize links the URLs in the text and colors it, with the matches of the
+// highlight regex, if any, in the match color instead.
+func (w *ConsoleWriter) colorize(s string, c *color.Color, highlight *regexp.Regexp) string {
+	sprint := func(s string, c *color.Color) string {
+		if c == nil || s == "" {
+			return w.linkURLs(s)
+		}
+		return c.Sprint(w.linkURLs(s))
+	}
+	if highlight == nil {
+		return sprint(s, c)
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range highlight.FindAllStringIndex(s, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		sb.WriteString(sprint(s[last:loc[0]], c))
+		sb.WriteString(sprint(s[loc[0]:loc[1]], w.Theme.Match))
+		last = loc[1]
+	}
+	sb.WriteString(sprint(s[last:], c))
+	return sb.String()
+}
+
+// formatMessage colorizes the message, where the properties are in the
+// property color.
+func (w *ConsoleWriter) formatMessage(msg string, properties [][2]int, highlight *regexp.Regexp) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range properties {
+		if span[0] < last || span[1] > len(msg) {
+			continue
+		}
+		sb.WriteString(w.colorize(msg[last:span[0]], nil, highlight))
+		sb.WriteString(w.colorize(msg[span[0]:span[1]], w.Theme.Property, highlight))
+		last = span[1]
+	}
+	sb.WriteString(w.colorize(msg[last:], nil, highlight))
+	return sb.String()
+}
+
+func (w *ConsoleWriter) formatFields(fields []Field, highlight *regexp.Regexp) []string {
+	formatted := make([]string, len(fields))
+	for i, field := range fields {
+		var buf bytes.Buffer
+		w.writeField(&buf, field, highlight)
+		formatted[i] = buf.String()
+	}
+	return formatted
+}
+
+func (w *ConsoleWriter) writeField(buf *bytes.Buffer, field Field, highlight *regexp.Regexp) {
+	buf.WriteString(w.Theme.FieldName.Sprint(field.Key + "="))
+	value := w.pad(field.Key, formatFieldValue(field.Value))
+	var c *color.Color
+	for _, rule := range w.FieldColorRules {
+		if rule.Match(field.Key, field.Value) {
+			c = rule.Color
+			break
+		}
+	}
+	if c == nil && field.Key == zerolog.ErrorFieldName {
+		c = w.Theme.ErrorValue
+	}
+	buf.WriteString(w.colorize(value, c, highlight))
+}
+
+// writeDump writes the goroutines of a dump under the entry, with runtime
+// frames collapsed unless RuntimeFrames is set.
+func (w *ConsoleWriter) writeDump(buf *bytes.Buffer, dump *GoroutineDump) {
+	for _, g := range dump.Groups {
+		buf.WriteByte('\t')
+		buf.WriteString(g.Header())
+		buf.WriteByte('\n')
+		for _, run := range runtimeRuns(g.Frames) {
+			if run[0].Runtime && !w.RuntimeFrames {
+				buf.WriteString("\t\t")
+				buf.WriteString(w.Theme.Dim.Sprint(runtimeFramesString(run)))
+				buf.WriteByte('\n')
+				continue
+			}
+			for _, f := range run {
+				buf.WriteString("\t\t")
+				buf.WriteString(w.formatFrame(f))
+				buf.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// formatFrame formats a stack frame on one line, where first-party frames
+// are highlighted.
+func (w *ConsoleWriter) formatFrame(f StackFrame) string {
+	file := relativeCaller(f.File)
+	if !f.FirstParty {
+		return f.Func + " " + w.Theme.Dim.Sprint(file)
+	}
+	if w.Links != nil {
+		file = w.Links.LinkCaller(f.File, file)
+	}
+	return w.Theme.Caller.Sprint(f.Func) + " " + file
+}
+
+// runtimeFramesString returns a note such as "… 3 runtime frames".
+func runtimeFramesString(frames []StackFrame) string {
+	if len(frames) == 1 {
+		return "… 1 runtime frame"
+	}
+	return fmt.Sprintf("… %d runtime frames", len(frames))
+}
+
+// repeatsString returns a note such as "(repeated 37× over 12s)".
+func repeatsString(e *Entry) string {
+	if e.RepeatSpan <= 0 {
+		return fmt.Sprintf("(repeated %d×)", e.Repeats)
+	}
+	span := e.RepeatSpan.Round(time.Millisecond)
+	if span >= time.Second {
+		span = span.Round(time.Second)
+	}
+	return fmt.Sprintf("(repeated %d× over %s)", e.Repeats, span)
+}
+
+func levelShortName(level zerolog.Level) string {
+	switch level {
+	case zerolog.TraceLevel:
+		return "TRC"
+	case zerolog.DebugLevel:
+		return "DBG"
+	case zerolog.InfoLevel:
+		return "INF"
+	case zerolog.WarnLevel:
+		return "WRN"
+	case zerolog.ErrorLevel:
+		return "ERR"
+	case zerolog.FatalLevel:
+		return "FTL"
+	case zerolog.PanicLevel:
+		return "PNC"
+	default:
+		return "???"
+	}
+}
+
+func relativeCaller(caller string) string {
+	if !filepath.IsAbs(caller) {
+		return caller
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, caller); err == nil {
+			return rel
+		}
+	}
+	return caller
+}
+
+// formatFieldValue formats a value the same way [zerolog.ConsoleWriter]
+// does, where strings are only quoted when needed.
+func formatFieldValue(value any) string {
+	str := fieldValueString(value)
+	switch value.(type) {
+	case string, error:
+		if needsQuote(str) {
+			return strconv.Quote(str)
+		}
+	}
+	return str
+}
+
+// fieldValueString returns the unquoted string representation of a value.
+func fieldValueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "[error: " + err.Error() + "]"
+		}
+		return string(b)
+	}
+}
+
+func needsQuote(s string) bool {
+	for i := range s {
+		if s[i] < 0x20 || s[i] > 0x7e || s[i] == ' ' || s[i] == '\\' || s[i] == '"' {
+			return true
+		}
+	}
+	return false
+}
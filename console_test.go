@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// setNoColor sets color.NoColor for the test, and restores it afterwards.
+func setNoColor(t *testing.T, noColor bool) {
+	old := color.NoColor
+	color.NoColor = noColor
+	t.Cleanup(func() { color.NoColor = old })
+}
+
+func relogToConsole(t *testing.T, input string, configure func(w *ConsoleWriter)) string {
+	t.Helper()
+	setNoColor(t, true)
+	var buf bytes.Buffer
+	w := &ConsoleWriter{Out: &buf, TimeFormat: "Jan-02 15:04", Theme: themes["dark"]}
+	configure(w)
+	r := NewRelogger(strings.NewReader(input), w, w.Theme)
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestConsoleWriterShowRaw(t *testing.T) {
+	containerLine := `2022-09-20T17:56:28.918274Z {"level":"info","message":"from container"}`
+	multiline := "{\n  \"level\": \"warn\",\n  \"message\": \"pretty printed\"\n}"
+	got := relogToConsole(t, containerLine+"\n"+multiline+"\n", func(w *ConsoleWriter) {
+		w.ShowRaw = true
+	})
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("want 2 entries with 5 raw lines, got %d lines:\n%s", len(lines), got)
+	}
+	if !strings.HasSuffix(lines[0], "INF from container") {
+		t.Errorf("want the parsed entry first, got %q", lines[0])
+	}
+	// The container timestamp is kept, as the line arrived.
+	assertEqualString(t, containerLine, lines[1], "raw line")
+	if !strings.HasSuffix(lines[2], "WRN pretty printed") {
+		t.Errorf("want one entry for the multi-line JSON, got %q", lines[2])
+	}
+	assertEqualString(t, multiline, strings.Join(lines[3:], "\n"), "raw lines")
+}
+
+func TestConsoleWriterRawOnUnparsed(t *testing.T) {
+	input := `{"level":"info","message":"json"}` + "\n" +
+		"level=warn msg=logfmt\n" +
+		"   some [plain] text\n"
+	got := relogToConsole(t, input, func(w *ConsoleWriter) {
+		w.RawOnUnparsed = true
+	})
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want 3 lines, got %d:\n%s", len(lines), got)
+	}
+	if !strings.HasSuffix(lines[0], "INF json") {
+		t.Errorf("want the JSON line formatted, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "WRN logfmt") {
+		t.Errorf("want the logfmt line formatted, got %q", lines[1])
+	}
+	assertEqualString(t, "   some [plain] text", lines[2], "unparsed line")
+}
+
+func TestConsoleWriterMessageProperties(t *testing.T) {
+	setNoColor(t, false)
+
+	w := &ConsoleWriter{Theme: themes["dark"]}
+	got := w.formatMessage(`User "bob" logged in`, [][2]int{{5, 10}}, nil)
+	want := `User ` + w.Theme.Property.Sprint(`"bob"`) + ` logged in`
+	assertEqualString(t, want, got, "message")
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Entry is a single parsed log entry, as produced by one of the processors.
+type Entry struct {
+	Level   zerolog.Level
+	Time    time.Time
+	Caller  string
+	Message string
+	Fields  []Field
+	// Raw holds the input lines the entry was parsed from, as they arrived.
+	Raw []string
+	// Unparsed is set when no processor recognized the format of the line.
+	Unparsed bool
+	// Continuation is set for indented lines that continue the previous
+	// entry, such as the lines of a stacktrace.
+	Continuation bool
+	// Properties are the start and end byte offsets of the values inserted
+	// into the message, such as the properties of message templates, which
+	// are colored by the writers.
+	Properties [][2]int
+	// Highlight marks the matches in the message and field values, as set
+	// by the [GrepWriter].
+	Highlight *regexp.Regexp
+	// Repeats is the number of times the entry was repeated in a row, as
+	// counted by the [CollapseWriter], over the RepeatSpan duration.
+	Repeats    int
+	RepeatSpan time.Duration
+	// Dump holds the goroutines of a Go panic or SIGQUIT dump.
+	Dump *GoroutineDump
+
+	emit func(*Entry)
+}
+
+// Field is a key-value pair of an [Entry]. The value is one of string, int64,
+// float64, bool, error, nil, [Object], []any, or any value decoded from JSON.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Object is a nested object that keeps the order of its keys.
+type Object []Field
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// asObject returns nested objects as an [Object], where regular maps get
+// their keys sorted.
+func asObject(value any) (Object, bool) {
+	switch v := value.(type) {
+	case Object:
+		return v, true
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		obj := make(Object, len(keys))
+		for i, key := range keys {
+			obj[i] = Field{Key: key, Value: v[key]}
+		}
+		return obj, true
+	default:
+		return nil, false
+	}
+}
+
+// Lookup returns the value of a field, or of the canonical "level", "time",
+// "message" and "caller" values. Nested values can be looked up with dotted
+// keys, such as "doc.driver.name".
+func (e *Entry) Lookup(key string) (any, bool) {
+	switch key {
+	case zerolog.LevelFieldName:
+		return e.Level.String(), e.Level != zerolog.NoLevel
+	case zerolog.TimestampFieldName:
+		return e.Time, !e.Time.IsZero()
+	case zerolog.MessageFieldName, "msg":
+		return e.Message, true
+	case zerolog.CallerFieldName:
+		return e.Caller, e.Caller != ""
+	}
+	return lookupField(e.Fields, key)
+}
+
+func lookupField(fields []Field, key string) (any, bool) {
+	for _, field := range fields {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	for _, field := range fields {
+		if !strings.HasPrefix(key, field.Key+".") {
+			continue
+		}
+		rest := key[len(field.Key)+1:]
+		if obj, ok := asObject(field.Value); ok {
+			if value, ok := lookupField(obj, rest); ok {
+				return value, true
+			}
+		} else if arr, ok := field.Value.([]any); ok {
+			index, suffix, _ := strings.Cut(rest, ".")
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 || i >= len(arr) {
+				continue
+			}
+			if suffix == "" {
+				return arr[i], true
+			}
+			if value, ok := lookupField([]Field{{Key: index, Value: arr[i]}}, rest); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// EntryWriter is where the relogger writes its entries to.
+type EntryWriter interface {
+	WriteEntry(e *Entry) error
+	Close() error
+}
+
+func (r *Relogger) newEntry(level zerolog.Level) *Entry {
+	return &Entry{
+		Level: level,
+		emit:  r.emit,
+	}
+}
+
+func (e *Entry) Str(key, value string) *Entry {
+	return e.Interface(key, value)
+}
+
+func (e *Entry) Int64(key string, value int64) *Entry {
+	return e.Interface(key, value)
+}
+
+func (e *Entry) Float64(key string, value float64) *Entry {
+	return e.Interface(key, value)
+}
+
+func (e *Entry) Bool(key string, value bool) *Entry {
+	return e.Interface(key, value)
+}
+
+func (e *Entry) Err(err error) *Entry {
+	return e.Interface(zerolog.ErrorFieldName, err)
+}
+
+func (e *Entry) Interface(key string, value any) *Entry {
+	e.Fields = append(e.Fields, Field{Key: key, Value: value})
+	return e
+}
+
+// Msg sets the message and sends the entry to the output.
+func (e *Entry) Msg(msg string) {
+	e.Message = msg
+	e.Time = parsedTime
+	e.emit(e)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type FieldSort string
+
+const (
+	// FieldSortSource keeps the fields in the order they appeared in the
+	// original log line.
+	FieldSortSource FieldSort = "source"
+	// FieldSortAlphabetical sorts the fields by their keys.
+	FieldSortAlphabetical FieldSort = "alphabetical"
+)
+
+func ParseFieldSort(s string) (FieldSort, error) {
+	switch fieldSort := FieldSort(s); fieldSort {
+	case FieldSortSource, FieldSortAlphabetical:
+		return fieldSort, nil
+	case "":
+		return FieldSortAlphabetical, nil
+	default:
+		return "", fmt.Errorf("unknown field order %q, must be one of: source, alphabetical", s)
+	}
+}
+
+// FieldOrder decides in which order the fields of an entry are printed.
+type FieldOrder struct {
+	// Priority lists fields that are printed first, in the given order.
+	Priority []string
+	// Sort decides the order of the remaining fields.
+	Sort FieldSort
+}
+
+// Apply returns a copy of the fields in the configured order.
+func (o FieldOrder) Apply(fields []Field) []Field {
+	ordered := make([]Field, 0, len(fields))
+	rest := make([]Field, 0, len(fields))
+	for _, field := range fields {
+		if o.priority(field.Key) < 0 {
+			rest = append(rest, field)
+		} else {
+			ordered = append(ordered, field)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return o.priority(ordered[i].Key) < o.priority(ordered[j].Key)
+	})
+	if o.Sort != FieldSortSource {
+		sort.SliceStable(rest, func(i, j int) bool {
+			return rest[i].Key < rest[j].Key
+		})
+	}
+	return append(ordered, rest...)
+}
+
+func (o FieldOrder) priority(key string) int {
+	for i, p := range o.Priority {
+		if p == key {
+			return i
+		}
+	}
+	return -1
+}
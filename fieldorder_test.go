@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestFieldOrderApply(t *testing.T) {
+	fields := []Field{
+		{Key: "uuid"},
+		{Key: "status"},
+		{Key: "component"},
+		{Key: "error"},
+		{Key: "request_id"},
+	}
+	tests := []struct {
+		name  string
+		order FieldOrder
+		want  []string
+	}{
+		{
+			name:  "alphabetical",
+			order: FieldOrder{Priority: []string{"error"}, Sort: FieldSortAlphabetical},
+			want:  []string{"error", "component", "request_id", "status", "uuid"},
+		},
+		{
+			name:  "source",
+			order: FieldOrder{Priority: []string{"request_id", "error"}, Sort: FieldSortSource},
+			want:  []string{"request_id", "error", "uuid", "status", "component"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.order.Apply(fields)
+			for i, field := range got {
+				assertEqualString(t, tc.want[i], field.Key, "field order")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// FieldSelection decides which fields of an entry are printed. Patterns are
+// globs matched against the dotted key of the field, such as "cluster.*",
+// and also apply to the keys inside nested objects.
+type FieldSelection struct {
+	// Include lists the fields to print. Empty means all fields.
+	Include []string
+	// Exclude lists the fields to leave out, even if included.
+	Exclude []string
+	// HideEmpty leaves out null values, empty strings, and empty objects
+	// and arrays.
+	HideEmpty bool
+}
+
+// Validate checks that all patterns are valid globs.
+func (s FieldSelection) Validate() error {
+	for _, pattern := range append(s.Include, s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid field pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Apply returns the selected fields.
+func (s FieldSelection) Apply(fields []Field) []Field {
+	if len(s.Include) == 0 && len(s.Exclude) == 0 && !s.HideEmpty {
+		return fields
+	}
+	return s.apply(fields, "", len(s.Include) == 0)
+}
+
+func (s FieldSelection) apply(fields []Field, prefix string, included bool) []Field {
+	selected := make([]Field, 0, len(fields))
+	for _, field := range fields {
+		key := prefix + field.Key
+		if matchAnyGlob(s.Exclude, key) {
+			continue
+		}
+		if s.HideEmpty && isEmptyValue(field.Value) {
+			continue
+		}
+		fieldIncluded := included || matchAnyGlob(s.Include, key)
+		if obj, ok := asObject(field.Value); ok {
+			children := s.apply(obj, key+".", fieldIncluded)
+			if len(children) == 0 && (len(obj) > 0 || !fieldIncluded) {
+				continue
+			}
+			selected = append(selected, Field{Key: field.Key, Value: Object(children)})
+			continue
+		}
+		if !fieldIncluded {
+			continue
+		}
+		selected = append(selected, field)
+	}
+	return selected
+}
+
+func matchAnyGlob(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case Object:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFieldSelectionApply(t *testing.T) {
+	fields := []Field{
+		{Key: "cluster.name", Value: "es"},
+		{Key: "cluster.uuid", Value: "abc"},
+		{Key: "type", Value: "server"},
+		{Key: "empty", Value: ""},
+		{Key: "none", Value: nil},
+		{Key: "doc", Value: Object{
+			{Key: "driver", Value: Object{{Key: "name", Value: "mongo"}, {Key: "version", Value: "5.0"}}},
+			{Key: "os", Value: "linux"},
+		}},
+	}
+	tests := []struct {
+		name      string
+		selection FieldSelection
+		want      string
+	}{
+		{
+			name:      "exclude glob",
+			selection: FieldSelection{Exclude: []string{"cluster.*", "doc.driver.version"}},
+			want:      `{"type":"server","empty":"","none":null,"doc":{"driver":{"name":"mongo"},"os":"linux"}}`,
+		},
+		{
+			name:      "include nested",
+			selection: FieldSelection{Include: []string{"type", "doc.driver.*"}},
+			want:      `{"type":"server","doc":{"driver":{"name":"mongo","version":"5.0"}}}`,
+		},
+		{
+			name:      "hide empty",
+			selection: FieldSelection{Include: []string{"type", "empty", "none"}, HideEmpty: true},
+			want:      `{"type":"server"}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(Object(tc.selection.Apply(fields)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqualString(t, tc.want, string(b), "selected fields")
+		})
+	}
+}
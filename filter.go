@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrStopReading is returned by writers that will not write any more
+// entries, so the rest of the input can be skipped.
+var ErrStopReading = errors.New("stop reading")
+
+// FilterWriter only passes on the entries that the filter keeps.
+// Continuation lines are kept or dropped together with the entry they
+// belong to.
+type FilterWriter struct {
+	Next EntryWriter
+	Keep func(e *Entry) bool
+	// Stop optionally reports that no later entries will be kept either,
+	// which makes WriteEntry return ErrStopReading.
+	Stop func(e *Entry) bool
+
+	seen     bool
+	keptLast bool
+}
+
+func (w *FilterWriter) WriteEntry(e *Entry) error {
+	if !e.Continuation || !w.seen {
+		if w.Stop != nil && w.Stop(e) {
+			return ErrStopReading
+		}
+		w.keptLast = w.Keep(e)
+		w.seen = true
+	}
+	if !w.keptLast {
+		return nil
+	}
+	return w.Next.WriteEntry(e)
+}
+
+func (w *FilterWriter) Close() error {
+	return w.Next.Close()
+}
+
+// LevelFilter keeps entries of at least the minimum level, or only of
+// exactly that level when only is set.
+type LevelFilter struct {
+	Level          zerolog.Level
+	Only           bool
+	IncludeNoLevel bool
+}
+
+func (f LevelFilter) Keep(e *Entry) bool {
+	if e.Level == zerolog.NoLevel {
+		return f.IncludeNoLevel
+	}
+	if f.Only {
+		return e.Level == f.Level
+	}
+	return e.Level >= f.Level
+}
+
+// TimeFilter keeps entries within the time range, where a zero Since or
+// Until leaves that end open. Entries without a timestamp use the time of
+// the entry before them, and are kept while no time has been seen yet.
+type TimeFilter struct {
+	Since time.Time
+	Until time.Time
+
+	last time.Time
+}
+
+func (f *TimeFilter) Keep(e *Entry) bool {
+	if !e.Time.IsZero() {
+		f.last = e.Time
+	}
+	if f.last.IsZero() {
+		return true
+	}
+	if !f.Since.IsZero() && f.last.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && f.last.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// PastUntil reports if the entry is past the end of the range. Only
+// meaningful for input in chronological order.
+func (f *TimeFilter) PastUntil(e *Entry) bool {
+	return !f.Until.IsZero() && e.Time.After(f.Until)
+}
+
+// SampleFilter keeps a fraction of the entries. With a key, entries are
+// sampled by a hash of that field, so that either all or none of the
+// entries with the same value are kept, such as all entries of a request.
+type SampleFilter struct {
+	Rate float64
+	Key  string
+	// KeepErrors lets entries of error level and higher bypass sampling.
+	KeepErrors bool
+
+	acc float64
+}
+
+func (f *SampleFilter) Keep(e *Entry) bool {
+	if f.KeepErrors && e.Level >= zerolog.ErrorLevel && e.Level != zerolog.NoLevel {
+		return true
+	}
+	if f.Key != "" {
+		if value, ok := e.Lookup(f.Key); ok {
+			h := fnv.New32a()
+			h.Write([]byte(fieldValueString(value)))
+			return float64(h.Sum32())/math.MaxUint32 < f.Rate
+		}
+	}
+	f.acc += f.Rate
+	if f.acc >= 1 {
+		f.acc--
+		return true
+	}
+	return false
+}
+
+var timeFlagLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimeFlag parses either a duration, such as "15m", meaning that long
+// before now, or an absolute time in local time unless a zone is given.
+func ParseTimeFlag(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeFlagLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, must be a duration like 15m or a time like 2006-01-02T15:04", s)
+}
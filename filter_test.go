@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type entryRecorder struct {
+	entries []*Entry
+}
+
+func (r *entryRecorder) WriteEntry(e *Entry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *entryRecorder) Close() error {
+	return nil
+}
+
+func (r *entryRecorder) messages() []string {
+	messages := make([]string, len(r.entries))
+	for i, e := range r.entries {
+		messages[i] = e.Message
+	}
+	return messages
+}
+
+func assertMessages(t *testing.T, want, got []string) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("want %d entries %q, got %d entries %q", len(want), want, len(got), got)
+	}
+	for i := range want {
+		assertEqualString(t, want[i], got[i], "entry message")
+	}
+}
+
+func TestLevelFilterContinuation(t *testing.T) {
+	rec := &entryRecorder{}
+	filter := LevelFilter{Level: zerolog.WarnLevel, IncludeNoLevel: false}
+	w := &FilterWriter{Next: rec, Keep: filter.Keep}
+
+	entries := []*Entry{
+		{Level: zerolog.InfoLevel, Message: "info"},
+		{Level: zerolog.NoLevel, Message: "  info continuation", Continuation: true},
+		{Level: zerolog.ErrorLevel, Message: "error"},
+		{Level: zerolog.NoLevel, Message: "  error continuation", Continuation: true},
+		{Level: zerolog.NoLevel, Message: "no level"},
+	}
+	for _, e := range entries {
+		w.WriteEntry(e)
+	}
+	assertMessages(t, []string{"error", "  error continuation"}, rec.messages())
+}
+
+func TestTimeFilterInheritsTime(t *testing.T) {
+	rec := &entryRecorder{}
+	base := time.Date(2022, 9, 20, 17, 0, 0, 0, time.UTC)
+	filter := &TimeFilter{Since: base.Add(10 * time.Minute), Until: base.Add(20 * time.Minute)}
+	w := &FilterWriter{Next: rec, Keep: filter.Keep, Stop: filter.PastUntil}
+
+	entries := []*Entry{
+		{Time: base, Message: "before"},
+		{Message: "no time before"},
+		{Time: base.Add(15 * time.Minute), Message: "within"},
+		{Message: "no time within"},
+		{Time: base.Add(25 * time.Minute), Message: "after"},
+	}
+	var err error
+	for _, e := range entries {
+		if err = w.WriteEntry(e); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrStopReading) {
+		t.Errorf("want ErrStopReading, got %v", err)
+	}
+	assertMessages(t, []string{"within", "no time within"}, rec.messages())
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2022, 9, 20, 18, 0, 0, 0, time.Local)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"15m", now.Add(-15 * time.Minute)},
+		{"2022-09-20T17:30", time.Date(2022, 9, 20, 17, 30, 0, 0, time.Local)},
+		{"2022-09-20T17:30:00Z", time.Date(2022, 9, 20, 17, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		got, err := ParseTimeFlag(tc.input, now)
+		if err != nil {
+			t.Errorf("%q: %v", tc.input, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("%q: want %s, got %s", tc.input, tc.want, got)
+		}
+	}
+}
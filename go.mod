@@ -6,8 +6,12 @@ require (
 	github.com/bytedance/sonic v1.8.7
 	github.com/fatih/color v1.15.0
 	github.com/go-logfmt/logfmt v0.6.0
+	github.com/mattn/go-runewidth v0.0.14
 	github.com/rs/zerolog v1.29.1
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/term v0.7.0
 	gopkg.in/typ.v4 v4.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -15,6 +19,7 @@ require (
 	github.com/klauspost/cpuid/v2 v2.2.4 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.18 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	golang.org/x/arch v0.3.0 // indirect
 	golang.org/x/sys v0.7.0 // indirect
@@ -0,0 +1,112 @@
+package main
+
+import "regexp"
+
+// GrepWriter only passes on the entries where the message or any field value
+// matches the pattern, together with a number of entries of context before
+// and after. An entry and its continuation lines count as one entry.
+type GrepWriter struct {
+	Next    EntryWriter
+	Pattern *regexp.Regexp
+	Before  int
+	After   int
+
+	cur        []*Entry
+	curWritten bool
+	before     [][]*Entry
+	afterLeft  int
+	wroteAny   bool
+	skipped    bool
+}
+
+// SeparatorWriter is implemented by outputs that can separate the groups of
+// entries written by the [GrepWriter].
+type SeparatorWriter interface {
+	WriteSeparator() error
+}
+
+func (w *GrepWriter) WriteEntry(e *Entry) error {
+	if e.Continuation && w.cur != nil {
+		w.cur = append(w.cur, e)
+		if w.curWritten {
+			return w.write(e)
+		}
+		if w.matches(e) {
+			return w.writeMatch()
+		}
+		return nil
+	}
+
+	if w.cur != nil && !w.curWritten {
+		w.before = append(w.before, w.cur)
+		if len(w.before) > w.Before {
+			w.before = w.before[1:]
+			w.skipped = true
+		}
+	}
+	w.cur = []*Entry{e}
+	w.curWritten = false
+
+	if w.matches(e) {
+		return w.writeMatch()
+	}
+	if w.afterLeft > 0 {
+		w.afterLeft--
+		w.curWritten = true
+		return w.write(e)
+	}
+	return nil
+}
+
+func (w *GrepWriter) Close() error {
+	return w.Next.Close()
+}
+
+func (w *GrepWriter) matches(e *Entry) bool {
+	if w.Pattern.MatchString(e.Message) {
+		return true
+	}
+	for _, field := range e.Fields {
+		if w.Pattern.MatchString(fieldValueString(field.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMatch writes the context before and the current entry, including its
+// continuation lines seen so far.
+func (w *GrepWriter) writeMatch() error {
+	// Like grep, the separator is only written when there is context.
+	hasContext := w.Before > 0 || w.After > 0
+	if hasContext && w.skipped && w.wroteAny {
+		if sep, ok := w.Next.(SeparatorWriter); ok {
+			if err := sep.WriteSeparator(); err != nil {
+				return err
+			}
+		}
+	}
+	w.skipped = false
+	for _, group := range w.before {
+		for _, e := range group {
+			if err := w.write(e); err != nil {
+				return err
+			}
+		}
+	}
+	w.before = w.before[:0]
+	for _, e := range w.cur {
+		if err := w.write(e); err != nil {
+			return err
+		}
+	}
+	w.curWritten = true
+	w.afterLeft = w.After
+	return nil
+}
+
+func (w *GrepWriter) write(e *Entry) error {
+	w.wroteAny = true
+	e.Highlight = w.Pattern
+	return w.Next.WriteEntry(e)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+type separatorRecorder struct {
+	entryRecorder
+}
+
+func (r *separatorRecorder) WriteSeparator() error {
+	r.entries = append(r.entries, &Entry{Message: "--"})
+	return nil
+}
+
+func TestGrepWriterContext(t *testing.T) {
+	rec := &separatorRecorder{}
+	w := &GrepWriter{Next: rec, Pattern: regexp.MustCompile("timeout"), Before: 1, After: 1}
+
+	entries := []*Entry{
+		{Message: "a"},
+		{Message: "b"},
+		{Message: "c"},
+		{Message: "  c continuation", Continuation: true},
+		{Message: "request failed", Fields: []Field{{Key: "error", Value: "timeout"}}},
+		{Message: "d"},
+		{Message: "  d continuation", Continuation: true},
+		{Message: "e"},
+		{Message: "f"},
+		{Message: "g"},
+		{Message: "h"},
+		{Message: "  at timeout.go:12", Continuation: true},
+		{Message: "  at main.go:3", Continuation: true},
+		{Message: "i"},
+	}
+	for _, e := range entries {
+		w.WriteEntry(e)
+	}
+	assertMessages(t, []string{
+		"c", "  c continuation", "request failed", "d", "  d continuation",
+		"--",
+		"g", "h", "  at timeout.go:12", "  at main.go:3", "i",
+	}, rec.messages())
+}
+
+func TestGrepWriterNoContext(t *testing.T) {
+	rec := &separatorRecorder{}
+	w := &GrepWriter{Next: rec, Pattern: regexp.MustCompile("timeout")}
+
+	for _, msg := range []string{"timeout 1", "a", "b", "timeout 2", "c", "timeout 3"} {
+		w.WriteEntry(&Entry{Message: msg})
+	}
+	assertMessages(t, []string{"timeout 1", "timeout 2", "timeout 3"}, rec.messages())
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// HTMLWriter writes entries as a self-contained HTML page, using the same
+// coloring as the console output. The page has collapsible stacktraces,
+// expandable nested fields, and a level filter.
+type HTMLWriter struct {
+	Out     io.Writer
+	Console *ConsoleWriter
+
+	wroteHeader bool
+}
+
+func (w *HTMLWriter) WriteEntry(e *Entry) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<div class="entry" data-level="%d">`, e.Level)
+	if e.Unparsed && w.Console.RawOnUnparsed {
+		buf.WriteString(ansiToHTML(strings.Join(e.Raw, "\n")))
+		buf.WriteString("</div>\n")
+		_, err := buf.WriteTo(w.Out)
+		return err
+	}
+
+	var head bytes.Buffer
+	w.Console.writeHeader(&head, e)
+	buf.WriteString(ansiToHTML(head.String()))
+
+	message, stacktrace, hasStacktrace := strings.Cut(e.Message, stacktraceHeader)
+	if message != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(`<span class="message">`)
+		buf.WriteString(ansiToHTML(w.Console.formatMessage(message, e.Properties, e.Highlight)))
+		buf.WriteString(`</span>`)
+	}
+	if e.Repeats > 1 {
+		buf.WriteByte(' ')
+		buf.WriteString(ansiToHTML(w.Console.Theme.Dim.Sprint(repeatsString(e))))
+	}
+
+	nestedMode := NestedTree
+	if w.Console.Nested.Mode == NestedFlatten {
+		nestedMode = NestedFlatten
+	}
+	fields, nested := w.Console.splitFields(e.Fields, nestedMode)
+	for _, field := range w.Console.formatFields(fields, e.Highlight) {
+		buf.WriteByte(' ')
+		buf.WriteString(`<span class="field">`)
+		buf.WriteString(ansiToHTML(field))
+		buf.WriteString(`</span>`)
+	}
+
+	if hasStacktrace {
+		buf.WriteString(`<details class="stacktrace"><summary>STACKTRACE</summary><pre>`)
+		buf.WriteString(ansiToHTML(strings.TrimRight(stacktrace, "\n\t")))
+		buf.WriteString(`</pre></details>`)
+	}
+	if e.Dump != nil {
+		w.writeDump(&buf, e.Dump)
+	}
+	for _, field := range nested {
+		var tree bytes.Buffer
+		children, collapsed := w.Console.Nested.children(field.Value)
+		w.Console.writeTree(&tree, children, "", 1)
+		if collapsed > 0 {
+			fmt.Fprintf(&tree, "… (%d more)\n", collapsed)
+		}
+		buf.WriteString(`<details class="nested"><summary>`)
+		buf.WriteString(ansiToHTML(w.Console.Theme.FieldName.Sprint(field.Key + "=")))
+		buf.WriteString(html.EscapeString(previewValue(field.Value)))
+		buf.WriteString(`</summary><pre>`)
+		buf.WriteString(ansiToHTML(tree.String()))
+		buf.WriteString(`</pre></details>`)
+	}
+	if w.Console.ShowRaw && len(e.Raw) > 0 {
+		buf.WriteString(`<pre class="raw">`)
+		buf.WriteString(ansiToHTML(w.Console.Theme.Dim.Sprint(strings.Join(e.Raw, "\n"))))
+		buf.WriteString(`</pre>`)
+	}
+	buf.WriteString("</div>\n")
+	_, err := buf.WriteTo(w.Out)
+	return err
+}
+
+// writeDump writes the goroutines of a dump, with the runtime frames
+// collapsed.
+func (w *HTMLWriter) writeDump(buf *bytes.Buffer, dump *GoroutineDump) {
+	fmt.Fprintf(buf, `<details class="goroutines" open><summary>%d GOROUTINES</summary>`, dump.Goroutines())
+	for _, g := range dump.Groups {
+		buf.WriteString(`<div class="goroutine">`)
+		buf.WriteString(html.EscapeString(g.Header()))
+		for _, run := range runtimeRuns(g.Frames) {
+			if run[0].Runtime {
+				buf.WriteString(`<details class="runtime"><summary>`)
+				buf.WriteString(ansiToHTML(w.Console.Theme.Dim.Sprint(runtimeFramesString(run))))
+				buf.WriteString(`</summary>`)
+			}
+			for _, f := range run {
+				buf.WriteString(`<div class="frame">`)
+				buf.WriteString(ansiToHTML(w.Console.formatFrame(f)))
+				buf.WriteString(`</div>`)
+			}
+			if run[0].Runtime {
+				buf.WriteString(`</details>`)
+			}
+		}
+		buf.WriteString(`</div>`)
+	}
+	buf.WriteString(`</details>`)
+}
+
+func (w *HTMLWriter) WriteSeparator() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.Out, "<hr>\n")
+	return err
+}
+
+func (w *HTMLWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.Out, htmlFooter)
+	return err
+}
+
+func (w *HTMLWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	_, err := io.WriteString(w.Out, htmlHeader)
+	return err
+}
+
+// previewValue returns the value as inline JSON, cut short when too long.
+func previewValue(value any) string {
+	const maxLen = 80
+	str := fieldValueString(value)
+	if len(str) > maxLen {
+		return str[:maxLen] + "…"
+	}
+	return str
+}
+
+var escapeRegex = regexp.MustCompile(`\x1b\[([0-9;]*)m|\x1b\]8;[^;\x1b]*;([^\x1b]*)\x1b\\`)
+
+// ansiToHTML escapes the text and translates ANSI SGR escape codes, as
+// written by the color package, into spans with CSS classes, and OSC 8
+// hyperlinks into anchors.
+func ansiToHTML(s string) string {
+	var sb strings.Builder
+	open := 0
+	last := 0
+	for _, loc := range escapeRegex.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(html.EscapeString(s[last:loc[0]]))
+		last = loc[1]
+		if loc[2] < 0 {
+			if u := s[loc[4]:loc[5]]; u != "" {
+				sb.WriteString(`<a href="` + html.EscapeString(u) + `">`)
+			} else {
+				sb.WriteString(`</a>`)
+			}
+			continue
+		}
+		codes := s[loc[2]:loc[3]]
+		if codes == "" || codes == "0" {
+			sb.WriteString(strings.Repeat("</span>", open))
+			open = 0
+			continue
+		}
+		sb.WriteString(`<span class="`)
+		for i, code := range strings.Split(codes, ";") {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString("sgr-")
+			sb.WriteString(code)
+		}
+		sb.WriteString(`">`)
+		open++
+	}
+	sb.WriteString(html.EscapeString(s[last:]))
+	sb.WriteString(strings.Repeat("</span>", open))
+	return sb.String()
+}
+
+var htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>relog</title>
+<style>
+:root { --bg: #1e1e1e; --fg: #d4d4d4; --bar: #2d2d2d; }
+@media (prefers-color-scheme: light) {
+  :root { --bg: #ffffff; --fg: #1e1e1e; --bar: #eeeeee; }
+}
+body { background: var(--bg); color: var(--fg); margin: 0; font: 13px/1.4 monospace; }
+header { position: sticky; top: 0; background: var(--bar); padding: 6px 8px; }
+main { padding: 8px; }
+.entry { white-space: pre-wrap; word-break: break-word; }
+.entry.hidden { display: none; }
+details { margin-left: 2em; }
+summary { cursor: pointer; }
+a { color: inherit; }
+pre { margin: 0 0 0 1em; font: inherit; }
+pre.raw { margin: 0; }
+.goroutine { margin-left: 1em; }
+.frame { margin-left: 2em; }
+details.runtime { margin-left: 2em; }
+hr { border: 0; border-top: 1px dashed var(--fg); opacity: 0.3; }
+.sgr-1 { font-weight: bold; } .sgr-2 { opacity: 0.7; } .sgr-3 { font-style: italic; }
+.sgr-4 { text-decoration: underline; } .sgr-7 { filter: invert(100%); }
+.sgr-30 { color: #000000; } .sgr-31 { color: #cd3131; } .sgr-32 { color: #0dbc79; }
+.sgr-33 { color: #e5e510; } .sgr-34 { color: #2472c8; } .sgr-35 { color: #bc3fbc; }
+.sgr-36 { color: #11a8cd; } .sgr-37 { color: #e5e5e5; }
+.sgr-90 { color: #767676; } .sgr-91 { color: #f14c4c; } .sgr-92 { color: #23d18b; }
+.sgr-93 { color: #f5f543; } .sgr-94 { color: #3b8eea; } .sgr-95 { color: #d670d6; }
+.sgr-96 { color: #29b8db; } .sgr-97 { color: #ffffff; }
+.sgr-40 { background: #000000; } .sgr-41 { background: #cd3131; } .sgr-42 { background: #0dbc79; }
+.sgr-43 { background: #e5e510; } .sgr-44 { background: #2472c8; } .sgr-45 { background: #bc3fbc; }
+.sgr-46 { background: #11a8cd; } .sgr-47 { background: #e5e5e5; }
+@media (prefers-color-scheme: light) {
+  .sgr-33 { color: #949800; } .sgr-93 { color: #b5ba00; } .sgr-37, .sgr-97 { color: #555555; }
+}
+</style>
+</head>
+<body>
+<header>
+<label>Minimum level
+<select id="level">
+<option value="` + fmt.Sprint(int(zerolog.TraceLevel)) + `">trace</option>
+<option value="` + fmt.Sprint(int(zerolog.DebugLevel)) + `">debug</option>
+<option value="` + fmt.Sprint(int(zerolog.InfoLevel)) + `">info</option>
+<option value="` + fmt.Sprint(int(zerolog.WarnLevel)) + `">warn</option>
+<option value="` + fmt.Sprint(int(zerolog.ErrorLevel)) + `">error</option>
+<option value="` + fmt.Sprint(int(zerolog.FatalLevel)) + `">fatal</option>
+</select>
+</label>
+<label><input type="checkbox" id="nolevel" checked> Entries without level</label>
+</header>
+<main>
+`
+
+var htmlFooter = `</main>
+<script>
+(function () {
+  var level = document.getElementById("level");
+  var noLevel = document.getElementById("nolevel");
+  function filter() {
+    var min = parseInt(level.value, 10);
+    document.querySelectorAll(".entry").forEach(function (entry) {
+      var l = parseInt(entry.dataset.level, 10);
+      var show = l === ` + fmt.Sprint(int(zerolog.NoLevel)) + ` ? noLevel.checked : l >= min;
+      entry.classList.toggle("hidden", !show);
+    });
+  }
+  level.addEventListener("change", filter);
+  noLevel.addEventListener("change", filter);
+})();
+</script>
+</body>
+</html>
+`
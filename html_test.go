@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAnsiToHTML(t *testing.T) {
+	got := ansiToHTML("\x1b[36;1mINF\x1b[0m a<b \x1b[31mred")
+	want := `<span class="sgr-36 sgr-1">INF</span> a&lt;b <span class="sgr-31">red</span>`
+	assertEqualString(t, want, got, "ANSI to HTML")
+}
+
+func TestHTMLWriterRaw(t *testing.T) {
+	setNoColor(t, true)
+	var out bytes.Buffer
+	console := &ConsoleWriter{TimeFormat: "Jan-02 15:04", Theme: themes["dark"], ShowRaw: true, RawOnUnparsed: true}
+	input := `{"level":"info","message":"json"}` + "\n" + "<plain> text\n"
+	r := NewRelogger(strings.NewReader(input), &HTMLWriter{Out: &out, Console: console}, console.Theme)
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if want := `<pre class="raw">{&#34;level&#34;:&#34;info&#34;,&#34;message&#34;:&#34;json&#34;}</pre>`; !strings.Contains(got, want) {
+		t.Errorf("want the raw JSON line below its entry, got:\n%s", got)
+	}
+	if want := fmt.Sprintf(`<div class="entry" data-level="%d">&lt;plain&gt; text</div>`, zerolog.NoLevel); !strings.Contains(got, want) {
+		t.Errorf("want the unparsed line as it is, got:\n%s", got)
+	}
+}
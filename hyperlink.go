@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Hyperlinker turns callers and URLs into clickable OSC 8 terminal
+// hyperlinks.
+type Hyperlinker struct {
+	// Rewrites maps path prefixes from the logs, such as the path inside a
+	// container, to paths on the local machine.
+	Rewrites []PathRewrite
+	// Template is the URL used for callers, where {path} and {line} are
+	// replaced, e.g "editor://open?file={path}&line={line}". Defaults to a
+	// file:// URL.
+	Template string
+	// Root is the directory that relative caller paths are resolved from.
+	Root string
+}
+
+type PathRewrite struct {
+	From string
+	To   string
+}
+
+// osc8 wraps the text in an OSC 8 hyperlink escape sequence.
+func osc8(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+var callerPathRegex = regexp.MustCompile(`^([^\s:()\[\]]+\.\w+):(\d+)`)
+
+// CallerURL returns the URL to the source code of a caller such as
+// "compact.go:519" or "Foo.java:208 (com.example.Foo:bar)".
+func (h *Hyperlinker) CallerURL(caller string) (string, bool) {
+	groups := callerPathRegex.FindStringSubmatch(caller)
+	if groups == nil {
+		return "", false
+	}
+	path, line := groups[1], groups[2]
+	for _, rewrite := range h.Rewrites {
+		if strings.HasPrefix(path, rewrite.From) {
+			path = rewrite.To + strings.TrimPrefix(path, rewrite.From)
+			break
+		}
+	}
+	if !filepath.IsAbs(path) && h.Root != "" {
+		path = filepath.Join(h.Root, path)
+	}
+	if h.Template == "" {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+		return u.String(), true
+	}
+	return strings.NewReplacer("{path}", path, "{line}", line).Replace(h.Template), true
+}
+
+// LinkCaller wraps the caller in a hyperlink, but leaves any padding
+// outside of the link.
+func (h *Hyperlinker) LinkCaller(caller, text string) string {
+	u, ok := h.CallerURL(caller)
+	if !ok {
+		return text
+	}
+	trimmed := strings.TrimRight(text, " ")
+	return osc8(u, trimmed) + text[len(trimmed):]
+}
+
+var urlRegex = regexp.MustCompile(`\bhttps?://[^\s"'<>\x1b]+[^\s"'<>\x1b.,;:!?)\]]`)
+
+// LinkURLs wraps all URLs found in the text in hyperlinks.
+func (h *Hyperlinker) LinkURLs(s string) string {
+	return urlRegex.ReplaceAllStringFunc(s, func(u string) string {
+		return osc8(u, u)
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestHyperlinkerCallerURL(t *testing.T) {
+	h := Hyperlinker{
+		Root:     "/home/me/app",
+		Rewrites: []PathRewrite{{From: "/go/src/app/", To: "/home/me/app/"}},
+	}
+	tests := []struct {
+		caller string
+		want   string
+	}{
+		{caller: "compact.go:519", want: "file:///home/me/app/compact.go"},
+		{caller: "/go/src/app/pkg/x.go:12", want: "file:///home/me/app/pkg/x.go"},
+		{caller: "Foo.java:208 (com.example.Foo:bar)", want: "file:///home/me/app/Foo.java"},
+	}
+	for _, tc := range tests {
+		got, ok := h.CallerURL(tc.caller)
+		if !ok {
+			t.Errorf("caller %q: no URL", tc.caller)
+			continue
+		}
+		assertEqualString(t, tc.want, got, tc.caller)
+	}
+
+	h.Template = "editor://open?file={path}&line={line}"
+	got, _ := h.CallerURL("compact.go:519")
+	assertEqualString(t, "editor://open?file=/home/me/app/compact.go&line=519", got, "template")
+
+	if _, ok := h.CallerURL("[NETWORK|listener|22943]"); ok {
+		t.Error("expected no URL for MongoDB caller")
+	}
+}
+
+func TestHyperlinkerLinkURLs(t *testing.T) {
+	var h Hyperlinker
+	got := h.LinkURLs("see https://example.com/a?b=1.")
+	want := "see \x1b]8;;https://example.com/a?b=1\x1b\\https://example.com/a?b=1\x1b]8;;\x1b\\."
+	assertEqualString(t, want, got, "link URLs")
+}
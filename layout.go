@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+type LayoutMode string
+
+const (
+	// LayoutOverflow writes all fields on the same line as the message and
+	// leaves it to the terminal to wrap them.
+	LayoutOverflow LayoutMode = "overflow"
+	// LayoutWrap soft-wraps the fields onto the following lines, indented
+	// to line up after the level.
+	LayoutWrap LayoutMode = "wrap"
+	// LayoutTruncate cuts the fields at the terminal width, but always
+	// keeps the message.
+	LayoutTruncate LayoutMode = "truncate"
+)
+
+func ParseLayoutMode(s string) (LayoutMode, error) {
+	switch mode := LayoutMode(s); mode {
+	case LayoutOverflow, LayoutWrap, LayoutTruncate:
+		return mode, nil
+	case "":
+		return LayoutOverflow, nil
+	default:
+		return "", fmt.Errorf("unknown layout %q, must be one of: overflow, wrap, truncate", s)
+	}
+}
+
+type Layout struct {
+	Mode LayoutMode
+	// Width is the fixed width in terminal cells. Zero means to use the
+	// width of the terminal, if any.
+	Width int
+	// Indent is the hanging indentation used when wrapping.
+	Indent int
+	// Terminal is the file descriptor used to detect the terminal width.
+	Terminal *os.File
+}
+
+// width returns the current width, or zero for no limit. The terminal size
+// is looked up on every call so that the layout follows window resizes.
+func (l Layout) width() int {
+	if l.Width > 0 {
+		return l.Width
+	}
+	if l.Terminal == nil || !term.IsTerminal(int(l.Terminal.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(l.Terminal.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// writeFields writes the already formatted fields after what has been
+// written to the buffer so far.
+func (l Layout) writeFields(buf *bytes.Buffer, fields []string) {
+	width := 0
+	if l.Mode != LayoutOverflow && len(fields) > 0 {
+		width = l.width()
+	}
+	if width <= 0 {
+		for _, field := range fields {
+			buf.WriteByte(' ')
+			buf.WriteString(field)
+		}
+		return
+	}
+	col := lastLineWidth(buf.String())
+	for _, field := range fields {
+		fieldWidth := displayWidth(field)
+		if col+1+fieldWidth <= width {
+			buf.WriteByte(' ')
+			buf.WriteString(field)
+			col += 1 + fieldWidth
+			continue
+		}
+		switch l.Mode {
+		case LayoutTruncate:
+			if remaining := width - col; remaining > 1 {
+				buf.WriteByte(' ')
+				buf.WriteString(truncateANSI(field, remaining-1, "…"))
+			} else if remaining == 1 {
+				buf.WriteString("…")
+			}
+			return
+		case LayoutWrap:
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(" ", l.Indent))
+			buf.WriteString(field)
+			col = l.Indent + fieldWidth
+		}
+	}
+}
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m|\x1b\]8;[^\x1b]*\x1b\\`)
+
+func stripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+const hyperlinkEnd = "\x1b]8;;\x1b\\"
+
+// truncateANSI cuts the string to the width in terminal cells, ending it
+// with the tail, the same as runewidth.Truncate, but keeps the ANSI escapes
+// so the visible part keeps its colors. Open colors and hyperlinks are
+// closed after the tail.
+func truncateANSI(s string, width int, tail string) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	limit := width - runewidth.StringWidth(tail)
+	var sb strings.Builder
+	var colored, inLink bool
+	col, i := 0, 0
+	escapes := ansiRegex.FindAllStringIndex(s, -1)
+	for i < len(s) {
+		if len(escapes) > 0 && escapes[0][0] == i {
+			escape := s[escapes[0][0]:escapes[0][1]]
+			if strings.HasPrefix(escape, "\x1b]8;") {
+				inLink = escape != hyperlinkEnd
+			} else {
+				colored = true
+			}
+			sb.WriteString(escape)
+			i = escapes[0][1]
+			escapes = escapes[1:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if col+runewidth.RuneWidth(r) > limit {
+			break
+		}
+		sb.WriteString(s[i : i+size])
+		col += runewidth.RuneWidth(r)
+		i += size
+	}
+	sb.WriteString(tail)
+	if inLink {
+		sb.WriteString(hyperlinkEnd)
+	}
+	if colored {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
+
+// displayWidth returns the width in terminal cells, ignoring ANSI escapes.
+func displayWidth(s string) int {
+	return runewidth.StringWidth(stripANSI(s))
+}
+
+func lastLineWidth(s string) int {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return displayWidth(strings.ReplaceAll(s, "\t", "        "))
+}
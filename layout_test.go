@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLayoutWriteFields(t *testing.T) {
+	fields := []string{"a=1", "bb=22", "ccc=333"}
+	tests := []struct {
+		name   string
+		layout Layout
+		want   string
+	}{
+		{
+			name:   "overflow",
+			layout: Layout{Mode: LayoutOverflow, Width: 10},
+			want:   "msg a=1 bb=22 ccc=333",
+		},
+		{
+			name:   "wrap",
+			layout: Layout{Mode: LayoutWrap, Width: 14, Indent: 2},
+			want:   "msg a=1 bb=22\n  ccc=333",
+		},
+		{
+			name:   "truncate",
+			layout: Layout{Mode: LayoutTruncate, Width: 16},
+			want:   "msg a=1 bb=22 c…",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			buf.WriteString("msg")
+			tc.layout.writeFields(&buf, fields)
+			assertEqualString(t, tc.want, buf.String(), "layout")
+		})
+	}
+}
+
+func TestTruncateANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{
+			name:  "fits",
+			input: "\x1b[31mab\x1b[0m",
+			width: 2,
+			want:  "\x1b[31mab\x1b[0m",
+		},
+		{
+			name:  "keeps color",
+			input: "\x1b[36mkey=\x1b[0m\x1b[31mvalue\x1b[0m",
+			width: 6,
+			want:  "\x1b[36mkey=\x1b[0m\x1b[31mv…\x1b[0m",
+		},
+		{
+			name:  "closes hyperlink",
+			input: "\x1b]8;;file:///a.go\x1b\\a.go:12\x1b]8;;\x1b\\",
+			width: 3,
+			want:  "\x1b]8;;file:///a.go\x1b\\a.…\x1b]8;;\x1b\\",
+		},
+		{
+			name:  "wide runes",
+			input: "\x1b[31m日本語\x1b[0m",
+			width: 4,
+			want:  "\x1b[31m日…\x1b[0m",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assertEqualString(t, tc.want, truncateANSI(tc.input, tc.width, "…"), "truncated")
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelScale decides how numeric levels are mapped to zerolog levels.
+type LevelScale string
+
+const (
+	// LevelScaleAuto picks the scale from the name of the field and the
+	// range of the value.
+	LevelScaleAuto LevelScale = "auto"
+	// LevelScalePino is used by pino and bunyan: 10 trace to 60 fatal.
+	LevelScalePino LevelScale = "pino"
+	// LevelScaleLogback is used by logback's level_value: 5000 trace to
+	// 50000 error.
+	LevelScaleLogback LevelScale = "logback"
+	// LevelScaleSyslog is the syslog severity: 0 emergency to 7 debug.
+	LevelScaleSyslog LevelScale = "syslog"
+	// LevelScaleOTel is the OpenTelemetry severity number: 1 trace to 24
+	// fatal.
+	LevelScaleOTel LevelScale = "otel"
+)
+
+func ParseLevelScale(s string) (LevelScale, error) {
+	switch scale := LevelScale(s); scale {
+	case LevelScaleAuto, LevelScalePino, LevelScaleLogback, LevelScaleSyslog, LevelScaleOTel:
+		return scale, nil
+	case "":
+		return LevelScaleAuto, nil
+	default:
+		return "", fmt.Errorf("unknown level scale %q, must be one of: auto, pino, logback, syslog, otel", s)
+	}
+}
+
+// levelScaleForKey picks the scale of the numeric level in the field with
+// the given key, unless a specific scale is configured.
+func levelScaleForKey(key string, scale LevelScale) LevelScale {
+	if scale != LevelScaleAuto {
+		return scale
+	}
+	switch key {
+	case "level_value":
+		return LevelScaleLogback
+	case "severityNumber", "severity_number":
+		return LevelScaleOTel
+	case "syslog.severity", "log.syslog.severity.code":
+		return LevelScaleSyslog
+	default:
+		return LevelScaleAuto
+	}
+}
+
+// parseNumericLevel maps a numeric level of the scale. The auto scale picks
+// it from the range of the value.
+func parseNumericLevel(n int64, scale LevelScale) zerolog.Level {
+	if scale == LevelScaleAuto {
+		switch {
+		case n >= 1000:
+			scale = LevelScaleLogback
+		case n >= 10 && n%10 == 0:
+			scale = LevelScalePino
+		case n >= 0 && n <= 7:
+			scale = LevelScaleSyslog
+		default:
+			return zerolog.NoLevel
+		}
+	}
+	switch scale {
+	case LevelScalePino:
+		switch {
+		case n < 10:
+			return zerolog.NoLevel
+		case n < 20:
+			return zerolog.TraceLevel
+		case n < 30:
+			return zerolog.DebugLevel
+		case n < 40:
+			return zerolog.InfoLevel
+		case n < 50:
+			return zerolog.WarnLevel
+		case n < 60:
+			return zerolog.ErrorLevel
+		default:
+			return zerolog.FatalLevel
+		}
+	case LevelScaleLogback:
+		switch {
+		case n < 5000:
+			return zerolog.NoLevel
+		case n < 10000:
+			return zerolog.TraceLevel
+		case n < 20000:
+			return zerolog.DebugLevel
+		case n < 30000:
+			return zerolog.InfoLevel
+		case n < 40000:
+			return zerolog.WarnLevel
+		case n < 50000:
+			return zerolog.ErrorLevel
+		default:
+			return zerolog.FatalLevel
+		}
+	case LevelScaleSyslog:
+		switch n {
+		case 0:
+			return zerolog.PanicLevel
+		case 1, 2:
+			return zerolog.FatalLevel
+		case 3:
+			return zerolog.ErrorLevel
+		case 4:
+			return zerolog.WarnLevel
+		case 5, 6:
+			return zerolog.InfoLevel
+		case 7:
+			return zerolog.DebugLevel
+		default:
+			return zerolog.NoLevel
+		}
+	case LevelScaleOTel:
+		switch {
+		case n < 1 || n > 24:
+			return zerolog.NoLevel
+		case n <= 4:
+			return zerolog.TraceLevel
+		case n <= 8:
+			return zerolog.DebugLevel
+		case n <= 12:
+			return zerolog.InfoLevel
+		case n <= 16:
+			return zerolog.WarnLevel
+		case n <= 20:
+			return zerolog.ErrorLevel
+		default:
+			return zerolog.FatalLevel
+		}
+	default:
+		return zerolog.NoLevel
+	}
+}
+
+// parseLevelWithScale parses a level name, or a numeric level of the scale.
+func parseLevelWithScale(levelStr string, scale LevelScale) zerolog.Level {
+	if n, err := strconv.ParseInt(levelStr, 10, 64); err == nil {
+		return parseNumericLevel(n, scale)
+	}
+	return parseLevel(levelStr)
+}
+
+// parseLevelFilter parses a level given by the user, such as to --level or
+// --where. Numbers are only accepted with a specific scale, as the auto scale
+// would read 1 as syslog's alert, which is fatal, and not as pino's trace.
+func parseLevelFilter(levelStr string, scale LevelScale) (zerolog.Level, error) {
+	n, err := strconv.ParseInt(levelStr, 10, 64)
+	if err != nil {
+		if level := parseLevel(levelStr); level != zerolog.NoLevel {
+			return level, nil
+		}
+		return zerolog.NoLevel, fmt.Errorf("unknown level %q", levelStr)
+	}
+	if scale == LevelScaleAuto {
+		return zerolog.NoLevel, fmt.Errorf("numeric level %q needs a level scale, such as --level-scale pino", levelStr)
+	}
+	if level := parseNumericLevel(n, scale); level != zerolog.NoLevel {
+		return level, nil
+	}
+	return zerolog.NoLevel, fmt.Errorf("level %q is outside the %s scale", levelStr, scale)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestParseNumericLevel(t *testing.T) {
+	tests := []struct {
+		n     int64
+		scale LevelScale
+		want  zerolog.Level
+	}{
+		{30, LevelScaleAuto, zerolog.InfoLevel},
+		{50, LevelScaleAuto, zerolog.ErrorLevel},
+		{60, LevelScalePino, zerolog.FatalLevel},
+		{20000, LevelScaleAuto, zerolog.InfoLevel},
+		{40000, LevelScaleLogback, zerolog.ErrorLevel},
+		{3, LevelScaleAuto, zerolog.ErrorLevel},
+		{6, LevelScaleSyslog, zerolog.InfoLevel},
+		{9, LevelScaleOTel, zerolog.InfoLevel},
+		{17, LevelScaleOTel, zerolog.ErrorLevel},
+		{21, LevelScaleOTel, zerolog.FatalLevel},
+		{99, LevelScaleAuto, zerolog.NoLevel},
+	}
+	for _, tc := range tests {
+		if got := parseNumericLevel(tc.n, tc.scale); got != tc.want {
+			t.Errorf("%d (%s): want %s, got %s", tc.n, tc.scale, tc.want, got)
+		}
+	}
+}
+
+func TestProcessJSONNumericLevels(t *testing.T) {
+	tests := []struct {
+		input string
+		want  zerolog.Level
+	}{
+		{`{"level":30,"time":1663696588918,"pid":1,"hostname":"x","msg":"pino"}`, zerolog.InfoLevel},
+		{`{"@timestamp":"2022-09-20T17:56:28Z","level_value":30000,"message":"logback"}`, zerolog.WarnLevel},
+		{`{"severityNumber":17,"body":"otel"}`, zerolog.ErrorLevel},
+	}
+	for _, tc := range tests {
+		entries := relogString(t, tc.input+"\n")
+		if len(entries) != 1 {
+			t.Fatalf("want 1 entry, got %d", len(entries))
+		}
+		if entries[0].Level != tc.want {
+			t.Errorf("%s: want level %s, got %s", tc.input, tc.want, entries[0].Level)
+		}
+	}
+}
+
+func TestProcessLogfmtLevelValue(t *testing.T) {
+	entries := relogString(t, "level_value=20000 logger_name=akka.kafka.Consumer thread_name=main msg=started\n")
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	if entries[0].Level != zerolog.InfoLevel {
+		t.Errorf("want level info, got %s", entries[0].Level)
+	}
+	assertEqualString(t, "started", entries[0].Message, "message")
+}
+
+func TestParseLevelFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		scale   LevelScale
+		want    zerolog.Level
+		wantErr bool
+	}{
+		{input: "warn", scale: LevelScaleAuto, want: zerolog.WarnLevel},
+		{input: "1", scale: LevelScaleAuto, wantErr: true},
+		{input: "30", scale: LevelScaleAuto, wantErr: true},
+		{input: "30", scale: LevelScalePino, want: zerolog.InfoLevel},
+		{input: "1", scale: LevelScaleSyslog, want: zerolog.FatalLevel},
+		{input: "1", scale: LevelScalePino, wantErr: true},
+		{input: "loud", scale: LevelScalePino, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseLevelFilter(tc.input, tc.scale)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s (%s): want error, got %s", tc.input, tc.scale, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s (%s): %v", tc.input, tc.scale, err)
+		} else if got != tc.want {
+			t.Errorf("%s (%s): want %s, got %s", tc.input, tc.scale, tc.want, got)
+		}
+	}
+}
@@ -3,37 +3,496 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/bytedance/sonic/ast"
 	"github.com/fatih/color"
+	"github.com/jilleJr/relog/pkg/config"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
 )
 
 var parsedTime time.Time
 
+var flags = struct {
+	config            string
+	theme             string
+	noColor           bool
+	nested            string
+	nestedDepth       int
+	nestedArrayItems  int
+	priorityFields    []string
+	fieldOrder        string
+	alignCaller       bool
+	columns           []string
+	wrap              bool
+	truncate          bool
+	width             int
+	output            string
+	showRaw           bool
+	rawOnUnparsed     bool
+	hyperlinks        bool
+	hyperlinkTemplate string
+	level             string
+	levelOnly         string
+	includeNoLevel    bool
+	where             []string
+	regexp            []string
+	ignoreCase        bool
+	after             int
+	before            int
+	context           int
+	since             string
+	until             string
+	profile           string
+	fields            []string
+	excludeFields     []string
+	hideEmpty         bool
+	collapse          bool
+	collapseIgnore    []string
+	collapseIdle      time.Duration
+	levelScale        string
+	maxRate           string
+	sample            float64
+	sampleKey         string
+	keepErrors        bool
+	multilineStart    string
+	multilineMaxLines int
+	multilineTimeout  time.Duration
+	goModule          string
+	runtimeFrames     bool
+}{
+	config:            config.DefaultPath(),
+	theme:             "dark",
+	nested:            string(NestedInline),
+	nestedArrayItems:  10,
+	priorityFields:    []string{zerolog.ErrorFieldName},
+	fieldOrder:        string(FieldSortAlphabetical),
+	alignCaller:       true,
+	output:            "console",
+	includeNoLevel:    true,
+	collapseIdle:      time.Second,
+	keepErrors:        true,
+	multilineMaxLines: 500,
+	multilineTimeout:  time.Second,
+}
+
+func init() {
+	pflag.StringVar(&flags.config, "config", flags.config, "Path to config file")
+	pflag.StringVar(&flags.profile, "profile", flags.profile, "Name of a profile in the config to apply")
+	pflag.StringVar(&flags.theme, "theme", flags.theme, "Color theme, one of: "+strings.Join(themeNames(), ", "))
+	pflag.BoolVar(&flags.noColor, "no-color", flags.noColor, "Disable colored output")
+	pflag.StringVar(&flags.nested, "nested", flags.nested, "How to print nested JSON objects and arrays, one of: inline, tree, flatten")
+	pflag.IntVar(&flags.nestedDepth, "nested-depth", flags.nestedDepth, "Max depth to expand nested values, or 0 for no limit")
+	pflag.IntVar(&flags.nestedArrayItems, "nested-array-items", flags.nestedArrayItems, "Max array items to expand before collapsing the rest, or 0 for no limit")
+	pflag.StringSliceVar(&flags.priorityFields, "priority-fields", flags.priorityFields, "Fields to print first, in the given order")
+	pflag.StringSliceVar(&flags.fields, "fields", flags.fields, "Only print these fields, as glob patterns such as 'doc.*'")
+	pflag.StringSliceVar(&flags.excludeFields, "exclude-fields", flags.excludeFields, "Do not print these fields, as glob patterns such as 'cluster.*'")
+	pflag.BoolVar(&flags.hideEmpty, "hide-empty", flags.hideEmpty, "Do not print fields with null or empty values")
+	pflag.StringVar(&flags.fieldOrder, "field-order", flags.fieldOrder, "Order of the remaining fields, one of: source, alphabetical")
+	pflag.BoolVar(&flags.alignCaller, "align-caller", flags.alignCaller, "Pad the caller so messages line up")
+	pflag.StringSliceVar(&flags.columns, "columns", flags.columns, "Fields to pad so their values line up")
+	pflag.BoolVar(&flags.wrap, "wrap", flags.wrap, "Soft-wrap fields that do not fit within the terminal width")
+	pflag.BoolVar(&flags.truncate, "truncate", flags.truncate, "Cut fields that do not fit within the terminal width")
+	pflag.IntVar(&flags.width, "width", flags.width, "Width used by --wrap and --truncate (default: terminal width)")
+	pflag.BoolVar(&flags.showRaw, "show-raw", flags.showRaw, "Print the original input lines below each entry")
+	pflag.BoolVar(&flags.rawOnUnparsed, "raw-on-unparsed", flags.rawOnUnparsed, "Print lines in an unrecognized format as they are")
+	pflag.BoolVar(&flags.hyperlinks, "hyperlinks", flags.hyperlinks, "Turn callers and URLs into clickable terminal hyperlinks")
+	pflag.StringVar(&flags.hyperlinkTemplate, "hyperlink-template", flags.hyperlinkTemplate, `URL template for callers, e.g "editor://open?file={path}&line={line}" (default: file:// URL)`)
+	pflag.StringVar(&flags.multilineStart, "multiline-start", flags.multilineStart, `Regex of the lines that start a new entry, where other lines are joined to the entry before, e.g '^\d{4}-\d{2}-\d{2}'`)
+	pflag.IntVar(&flags.multilineMaxLines, "multiline-max-lines", flags.multilineMaxLines, "Max lines to join to one entry with --multiline-start, or 0 for no limit")
+	pflag.DurationVar(&flags.multilineTimeout, "multiline-timeout", flags.multilineTimeout, "Write the joined entry after the input has been idle this long, or 0 to wait for the next entry")
+	pflag.StringVar(&flags.goModule, "go-module", flags.goModule, "Module whose frames are highlighted in Go panics (default: from go.mod in the working directory)")
+	pflag.BoolVar(&flags.runtimeFrames, "runtime-frames", flags.runtimeFrames, "Show the runtime frames of Go panics instead of collapsing them")
+	pflag.StringVar(&flags.levelScale, "level-scale", flags.levelScale, "Scale of numeric levels, one of: auto, pino, logback, syslog, otel")
+	pflag.StringVarP(&flags.level, "level", "l", flags.level, "Only show entries of this level or higher")
+	pflag.StringVar(&flags.levelOnly, "level-only", flags.levelOnly, "Only show entries of exactly this level")
+	pflag.BoolVar(&flags.includeNoLevel, "include-no-level", flags.includeNoLevel, "Show entries without a level when filtering by level")
+	pflag.StringArrayVarP(&flags.where, "where", "w", flags.where, `Only show entries matching the expression, e.g 'status >= 500 && path =~ "^/api"'`)
+	pflag.StringVar(&flags.since, "since", flags.since, "Only show entries at or after this time, e.g 2022-09-20T17:30 or 15m for 15 minutes ago")
+	pflag.StringVar(&flags.until, "until", flags.until, "Only show entries at or before this time, e.g 2022-09-20T18:00 or 5m for 5 minutes ago")
+	pflag.BoolVar(&flags.collapse, "collapse", flags.collapse, "Collapse consecutive repeated entries into one")
+	pflag.StringSliceVar(&flags.collapseIgnore, "collapse-ignore", flags.collapseIgnore, "Fields that may differ between repeated entries, as glob patterns such as 'req_*'")
+	pflag.DurationVar(&flags.collapseIdle, "collapse-idle", flags.collapseIdle, "Write collapsed entries after the input has been idle this long")
+	pflag.StringVar(&flags.maxRate, "max-rate", flags.maxRate, "Drop entries above this rate, e.g 50/s or 1000/m")
+	pflag.Float64Var(&flags.sample, "sample", flags.sample, "Only keep this fraction of entries, e.g 0.1")
+	pflag.StringVar(&flags.sampleKey, "sample-key", flags.sampleKey, "Field to sample by, so all entries with the same value are kept or dropped together, e.g request_id")
+	pflag.BoolVar(&flags.keepErrors, "keep-errors", flags.keepErrors, "Let error entries bypass --max-rate and --sample")
+	pflag.StringArrayVarP(&flags.regexp, "regexp", "e", flags.regexp, "Only show entries where the message or a field value matches the regex")
+	pflag.BoolVarP(&flags.ignoreCase, "ignore-case", "i", flags.ignoreCase, "Match --regexp patterns case-insensitively")
+	pflag.IntVarP(&flags.after, "after-context", "A", flags.after, "Show this many entries after each --regexp match")
+	pflag.IntVarP(&flags.before, "before-context", "B", flags.before, "Show this many entries before each --regexp match")
+	pflag.IntVarP(&flags.context, "context", "C", flags.context, "Show this many entries before and after each --regexp match")
+	pflag.StringVarP(&flags.output, "output", "o", flags.output, "Output format, one of: console, html")
+}
+
+// applyFlags lets the flags override the config. Flags that are not set by
+// the user only act as defaults for the config.
+func applyFlags(cfg *config.Config) {
+	changed := pflag.CommandLine.Changed
+	if changed("theme") || cfg.Theme == "" {
+		cfg.Theme = flags.theme
+	}
+	if changed("nested") || cfg.Nested.Mode == "" {
+		cfg.Nested.Mode = flags.nested
+	}
+	if changed("nested-depth") || cfg.Nested.MaxDepth == 0 {
+		cfg.Nested.MaxDepth = flags.nestedDepth
+	}
+	if changed("nested-array-items") || cfg.Nested.MaxArrayItems == 0 {
+		cfg.Nested.MaxArrayItems = flags.nestedArrayItems
+	}
+	if changed("priority-fields") || cfg.Fields.Priority == nil {
+		cfg.Fields.Priority = flags.priorityFields
+	}
+	if changed("field-order") || cfg.Fields.Order == "" {
+		cfg.Fields.Order = flags.fieldOrder
+	}
+	if changed("fields") {
+		cfg.Fields.Include = flags.fields
+	}
+	if changed("exclude-fields") {
+		cfg.Fields.Exclude = flags.excludeFields
+	}
+	if changed("hide-empty") {
+		cfg.Fields.HideEmpty = flags.hideEmpty
+	}
+	if changed("level-scale") {
+		cfg.LevelScale = flags.levelScale
+	}
+	if changed("multiline-start") {
+		cfg.Multiline.Start = flags.multilineStart
+	}
+	if changed("multiline-max-lines") || cfg.Multiline.MaxLines == nil {
+		cfg.Multiline.MaxLines = &flags.multilineMaxLines
+	}
+	if changed("multiline-timeout") || cfg.Multiline.Timeout == nil {
+		cfg.Multiline.Timeout = &flags.multilineTimeout
+	}
+	if changed("align-caller") || cfg.Columns.Caller == nil {
+		cfg.Columns.Caller = &flags.alignCaller
+	}
+	if changed("columns") {
+		cfg.Columns.Fields = flags.columns
+	}
+	if flags.wrap {
+		cfg.Layout.Mode = string(LayoutWrap)
+	} else if flags.truncate {
+		cfg.Layout.Mode = string(LayoutTruncate)
+	}
+	if changed("width") {
+		cfg.Layout.Width = flags.width
+	}
+	if changed("hyperlinks") {
+		cfg.Hyperlinks.Enabled = flags.hyperlinks
+	}
+	if changed("hyperlink-template") {
+		cfg.Hyperlinks.Template = flags.hyperlinkTemplate
+	}
+}
+
 func main() {
+	pflag.Parse()
+	color.NoColor = flags.noColor
 	loggerSetup()
-	relogger := NewRelogger(os.Stdin)
+
+	if flags.wrap && flags.truncate {
+		log.Fatal().Msg("Flags --wrap and --truncate cannot be used together.")
+	}
+	if flags.level != "" && flags.levelOnly != "" {
+		log.Fatal().Msg("Flags --level and --level-only cannot be used together.")
+	}
+	if flags.output != "console" && flags.output != "html" {
+		log.Fatal().Str("output", flags.output).Msg("Unknown output format, must be one of: console, html.")
+	}
+
+	cfg, err := config.Load(flags.config, !pflag.CommandLine.Changed("config"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config.")
+	}
+	if flags.profile != "" {
+		if err := cfg.ApplyProfile(flags.profile); err != nil {
+			log.Fatal().Err(err).Msg("Failed to apply profile.")
+		}
+	}
+	applyFlags(&cfg)
+	if flags.output == "html" {
+		// The HTML writer reuses the console coloring, and translates it to
+		// HTML, so the colors must always be enabled.
+		color.NoColor = false
+		cfg.Layout.Mode = string(LayoutOverflow)
+	}
+	console, err := newConsoleWriter(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid config.")
+	}
+	console.ShowRaw = flags.showRaw
+	console.RawOnUnparsed = flags.rawOnUnparsed
+	console.RuntimeFrames = flags.runtimeFrames
+	var out EntryWriter = console
+	if flags.output == "html" {
+		console.Out = os.Stdout
+		out = &HTMLWriter{Out: os.Stdout, Console: console}
+	}
+	levelScale, err := ParseLevelScale(cfg.LevelScale)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid config.")
+	}
+	out, err = newFilters(out, levelScale)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid filter.")
+	}
+	relogger := NewRelogger(os.Stdin, out, console.Theme)
+	relogger.jsonKeys = NewJSONKeys(cfg.Keys)
+	relogger.levelScale = levelScale
+	relogger.goModule = flags.goModule
+	if relogger.goModule == "" {
+		relogger.goModule = detectGoModule()
+	}
+	relogger.multiline, err = NewMultilineRule(cfg.Multiline.Start, *cfg.Multiline.MaxLines, *cfg.Multiline.Timeout)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid config.")
+	}
 
 	if err := relogger.RelogAll(); err != nil {
 		log.Err(err).Msg("Failed to scan.")
 	}
+	if err := out.Close(); err != nil {
+		log.Err(err).Msg("Failed to close output.")
+	}
 }
 
-func NewRelogger(r io.Reader) Relogger {
-	return Relogger{
-		scanner:      bufio.NewScanner(os.Stdin),
+// newFilters wraps the output in the filters enabled by the flags, where
+// numeric levels are read with the level scale.
+func newFilters(out EntryWriter, levelScale LevelScale) (EntryWriter, error) {
+	if flags.maxRate != "" {
+		rate, err := ParseRate(flags.maxRate)
+		if err != nil {
+			return nil, fmt.Errorf("--max-rate: %w", err)
+		}
+		out = &RateLimitWriter{Next: out, Rate: rate, KeepErrors: flags.keepErrors, Idle: suppressedMarkerInterval}
+	}
+	if flags.collapse {
+		out = &CollapseWriter{Next: out, Ignore: flags.collapseIgnore, Idle: flags.collapseIdle}
+	}
+	if len(flags.regexp) > 0 {
+		grep, err := newGrepWriter(out)
+		if err != nil {
+			return nil, err
+		}
+		out = grep
+	}
+	if flags.level != "" || flags.levelOnly != "" {
+		levelStr, only := flags.level, false
+		if flags.levelOnly != "" {
+			levelStr, only = flags.levelOnly, true
+		}
+		level, err := parseLevelFilter(levelStr, levelScale)
+		if err != nil {
+			return nil, err
+		}
+		filter := LevelFilter{Level: level, Only: only, IncludeNoLevel: flags.includeNoLevel}
+		out = &FilterWriter{Next: out, Keep: filter.Keep}
+	}
+	if flags.since != "" || flags.until != "" {
+		filter, err := newTimeFilter()
+		if err != nil {
+			return nil, err
+		}
+		w := &FilterWriter{Next: out, Keep: filter.Keep}
+		if isRegularFile(os.Stdin) {
+			// Files are assumed to be in chronological order, unlike
+			// pipes that may interleave multiple sources.
+			w.Stop = filter.PastUntil
+		}
+		out = w
+	}
+	if pflag.CommandLine.Changed("sample") {
+		if flags.sample <= 0 || flags.sample > 1 {
+			return nil, fmt.Errorf("--sample must be above 0 and at most 1, but got %v", flags.sample)
+		}
+		filter := &SampleFilter{Rate: flags.sample, Key: flags.sampleKey, KeepErrors: flags.keepErrors}
+		out = &FilterWriter{Next: out, Keep: filter.Keep}
+	}
+	for _, where := range flags.where {
+		expr, err := ParseWhere(where, levelScale)
+		if err != nil {
+			return nil, fmt.Errorf("--where %q: %w", where, err)
+		}
+		out = &FilterWriter{Next: out, Keep: expr.Eval}
+	}
+	return out, nil
+}
+
+func newTimeFilter() (*TimeFilter, error) {
+	var filter TimeFilter
+	now := time.Now()
+	if flags.since != "" {
+		t, err := ParseTimeFlag(flags.since, now)
+		if err != nil {
+			return nil, fmt.Errorf("--since: %w", err)
+		}
+		filter.Since = t
+	}
+	if flags.until != "" {
+		t, err := ParseTimeFlag(flags.until, now)
+		if err != nil {
+			return nil, fmt.Errorf("--until: %w", err)
+		}
+		filter.Until = t
+	}
+	return &filter, nil
+}
+
+func isRegularFile(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// newGrepWriter combines the --regexp patterns into one, so any of them
+// matching keeps the entry.
+func newGrepWriter(out EntryWriter) (*GrepWriter, error) {
+	patterns := make([]string, len(flags.regexp))
+	for i, pattern := range flags.regexp {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("--regexp %q: %w", pattern, err)
+		}
+		patterns[i] = "(?:" + pattern + ")"
+	}
+	combined := strings.Join(patterns, "|")
+	if flags.ignoreCase {
+		combined = "(?i)" + combined
+	}
+	grep := &GrepWriter{
+		Next:    out,
+		Pattern: regexp.MustCompile(combined),
+		Before:  flags.context,
+		After:   flags.context,
+	}
+	if flags.before > 0 {
+		grep.Before = flags.before
+	}
+	if flags.after > 0 {
+		grep.After = flags.after
+	}
+	return grep, nil
+}
+
+func newConsoleWriter(cfg config.Config) (*ConsoleWriter, error) {
+	theme, err := NewTheme(cfg.Theme, cfg.Colors)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := NewFieldColorRules(cfg.FieldColors)
+	if err != nil {
+		return nil, err
+	}
+	nestedMode, err := ParseNestedMode(cfg.Nested.Mode)
+	if err != nil {
+		return nil, err
+	}
+	fieldSort, err := ParseFieldSort(cfg.Fields.Order)
+	if err != nil {
+		return nil, err
+	}
+	selection := FieldSelection{
+		Include:   cfg.Fields.Include,
+		Exclude:   cfg.Fields.Exclude,
+		HideEmpty: cfg.Fields.HideEmpty,
+	}
+	if err := selection.Validate(); err != nil {
+		return nil, err
+	}
+	columnKeys := cfg.Columns.Fields
+	if *cfg.Columns.Caller {
+		columnKeys = append(columnKeys, zerolog.CallerFieldName)
+	}
+	layoutMode, err := ParseLayoutMode(cfg.Layout.Mode)
+	if err != nil {
+		return nil, err
+	}
+	window := cfg.Columns.Window
+	if window <= 0 {
+		window = 100
+	}
+	var links *Hyperlinker
+	if cfg.Hyperlinks.Enabled {
+		links = &Hyperlinker{
+			Root:     expandHome(cfg.Hyperlinks.Root),
+			Template: cfg.Hyperlinks.Template,
+		}
+		if links.Root == "" {
+			links.Root, _ = os.Getwd()
+		}
+		for _, rewrite := range cfg.Hyperlinks.Rewrites {
+			links.Rewrites = append(links.Rewrites, PathRewrite{
+				From: rewrite.From,
+				To:   expandHome(rewrite.To),
+			})
+		}
+	}
+	const timeFormat = "Jan-02 15:04"
+	return &ConsoleWriter{
+		Out:             color.Output,
+		TimeFormat:      timeFormat,
+		Theme:           theme,
+		FieldColorRules: rules,
+		Nested: NestedOptions{
+			Mode:          nestedMode,
+			MaxDepth:      cfg.Nested.MaxDepth,
+			MaxArrayItems: cfg.Nested.MaxArrayItems,
+		},
+		FieldOrder: FieldOrder{
+			Priority: cfg.Fields.Priority,
+			Sort:     fieldSort,
+		},
+		FieldSelection: selection,
+		Columns:        NewColumns(columnKeys, window),
+		Layout: Layout{
+			Mode:     layoutMode,
+			Width:    cfg.Layout.Width,
+			Indent:   len(timeFormat) + len(" INF "),
+			Terminal: os.Stdout,
+		},
+		Links: links,
+	}, nil
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
+func NewRelogger(r io.Reader, out EntryWriter, theme Theme) *Relogger {
+	return &Relogger{
+		scanner:      bufio.NewScanner(r),
+		out:          out,
+		theme:        theme,
 		mongoComp:    NewPaddedString(100),
 		mongoContext: NewPaddedString(100),
 		mongoID:      NewPaddedString(100),
+		jsonKeys:     defaultJSONKeys,
+		levelScale:   LevelScaleAuto,
+		goPanicIdle:  time.Second,
 	}
 }
 
@@ -45,32 +504,95 @@ const (
 	ProcessorLogfmt
 	ProcessorZap
 	ProcessorKlog
+	ProcessorPython
+	ProcessorGoPanic
 	ProcessorString
 )
 
 type Relogger struct {
 	scanner *bufio.Scanner
+	out     EntryWriter
+	theme   Theme
 
 	mongoComp    *PaddedString
 	mongoContext *PaddedString
 	mongoID      *PaddedString
 
+	jsonKeys   JSONKeys
+	levelScale LevelScale
+	// goModule is the path of the module whose stack frames are
+	// highlighted in goroutine dumps.
+	goModule string
+
 	lastProcessor   Processor
 	lastStringLevel zerolog.Level
 
 	buf bytes.Buffer
+	// raw holds the input lines of the entry currently being processed.
+	raw []string
+	// stopped is set when the output will not write any more entries.
+	stopped atomic.Bool
+
+	// multiline is nil when lines are not joined by a rule.
+	multiline *MultilineRule
+	// mu guards the pending entry, that is written from the timer of the
+	// multiline rule, and the output while a timer may write to it.
+	mu           sync.Mutex
+	pending      *Entry
+	pendingLines int
+	pendingAt    time.Time
+	timer        *time.Timer
+
+	// goPanic is the goroutine dump that is being read, if any, which is
+	// also guarded by mu.
+	goPanic      *goPanic
+	goPanicIdle  time.Duration
+	goPanicTimer *time.Timer
 }
 
 func (r *Relogger) RelogAll() error {
-	for r.scanner.Scan() {
+	for !r.stopped.Load() && r.scanner.Scan() {
 		r.processLine(r.scanner.Bytes())
 	}
+	r.flushGoPanic()
+	if r.multiline != nil {
+		r.flushPending()
+	}
 	return r.scanner.Err()
 }
 
+func (r *Relogger) emit(e *Entry) {
+	e.Raw = r.raw
+	r.raw = nil
+	if r.multiline != nil {
+		r.hold(e)
+		return
+	}
+	r.write(e)
+}
+
+func (r *Relogger) write(e *Entry) {
+	if err := r.out.WriteEntry(e); errors.Is(err, ErrStopReading) {
+		r.stopped.Store(true)
+	} else if err != nil {
+		log.Err(err).Msg("Failed to write entry.")
+	}
+}
+
 var containerTimestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d+Z `)
 
 func (r *Relogger) processLine(b []byte) {
+	if r.addGoPanicLine(b) {
+		return
+	}
+	if r.multiline != nil && r.buf.Len() == 0 && r.joinLine(b) {
+		return
+	}
+	if r.buf.Len() == 0 {
+		r.raw = nil
+	}
+	r.raw = append(r.raw, string(b))
+
 	parsedTime = time.Time{}
 	if timestamp := containerTimestampRegex.Find(b); timestamp != nil {
 		b = b[len(timestamp):]
@@ -80,6 +602,10 @@ func (r *Relogger) processLine(b []byte) {
 		}
 	}
 
+	if r.processLineGoPanic(b) {
+		r.lastProcessor = ProcessorGoPanic
+		return
+	}
 	if r.processLineJson(b) {
 		r.lastProcessor = ProcessorJSON
 		return
@@ -92,6 +618,10 @@ func (r *Relogger) processLine(b []byte) {
 		r.lastProcessor = ProcessorKlog
 		return
 	}
+	if r.processLinePython(b) {
+		r.lastProcessor = ProcessorPython
+		return
+	}
 	if r.processLineLogFmt(b) {
 		r.lastProcessor = ProcessorLogfmt
 		return
@@ -103,34 +633,28 @@ func (r *Relogger) processLine(b []byte) {
 type LevelRegex struct {
 	Regex *regexp.Regexp
 	Level zerolog.Level
-	Color *color.Color
 }
 
 var levelRegexes = []LevelRegex{
 	{
 		Regex: regexp.MustCompile(`(?:\[\s*)?\b(?:\d*m)?(?:ERROR|error|ERRO|erro|ERR|err|E\d+)\b\s*(?:\]\s*)?`),
 		Level: zerolog.ErrorLevel,
-		Color: color.New(color.FgRed, color.Bold),
 	},
 	{
 		Regex: regexp.MustCompile(`(?:\[\s*)?\b(?:\d*m)?(?:WARNING|warning|WARN|warn|WRN|wrn|W\d+)\b\s*(?:\]\s*)?`),
 		Level: zerolog.WarnLevel,
-		Color: color.New(color.FgRed),
 	},
 	{
 		Regex: regexp.MustCompile(`(?:\[\s*)?\b(?:\d*m)?(?:INFO|info|INF|inf|I\d+)\b\s*(?:\]\s*)?`),
 		Level: zerolog.InfoLevel,
-		Color: color.New(color.FgGreen),
 	},
 	{
 		Regex: regexp.MustCompile(`(?:\[\s*)?\b(?:\d*m)?(?:DEBUG|debug|DBG|dbg|D\d+)\b\s*(?:\]\s*)?`),
 		Level: zerolog.DebugLevel,
-		Color: color.New(color.FgYellow),
 	},
 	{
 		Regex: regexp.MustCompile(`(?:\[\s*)?\b(?:\d*m)?(?:TRACE|trace|TRC|trc|T\d+)\b\s*(?:\]\s*)?`),
 		Level: zerolog.TraceLevel,
-		Color: color.New(color.FgMagenta),
 	},
 }
 
@@ -164,6 +688,7 @@ func (r *Relogger) processLineString(s string) {
 		level = r.lastStringLevel
 	} else {
 		for _, matcher := range levelRegexes {
+			levelColor := r.theme.LevelColor(matcher.Level)
 			var matchedAny bool
 			replaced := matcher.Regex.ReplaceAllStringFunc(s, func(match string) string {
 				matchedAny = true
@@ -172,9 +697,9 @@ func (r *Relogger) processLineString(s string) {
 				}
 				ansiPart, cleanPart, ok := cutANSIPart(match)
 				if ok {
-					return ansiPart + matcher.Color.Sprint(cleanPart)
+					return ansiPart + levelColor.Sprint(cleanPart)
 				}
-				return matcher.Color.Sprint(match)
+				return levelColor.Sprint(match)
 			})
 			if matchedAny {
 				level = matcher.Level
@@ -184,11 +709,13 @@ func (r *Relogger) processLineString(s string) {
 		}
 	}
 
-	ev := log.WithLevel(level)
+	ev := r.newEntry(level)
+	ev.Unparsed = true
+	ev.Continuation = r.lastProcessor != ProcessorNone && startsWithWhitespace(s)
 
 	if inside, suffix, ok := cutParentheses(s, '[', ']'); ok {
 		s = suffix
-		ev = ev.Str("caller", inside)
+		ev.Caller = inside
 	}
 
 	ev.Msg(s)
@@ -215,34 +742,33 @@ func findWithAnyName(node ast.Node, names ...string) (string, *ast.Node) {
 	return name, child
 }
 
-func findManyWithAllNames(node ast.Node, names ...string) []*ast.Node {
-	nodes := make([]*ast.Node, len(names))
-	node.ForEach(func(path ast.Sequence, node *ast.Node) bool {
-		if path.Key == nil {
-			return false
-		}
-		key := *path.Key
-		for i, n := range names {
-			if key == n {
-				nodes[i] = node
-				return true
-			}
-		}
-		return true
-	})
-	for _, node := range nodes {
-		if node == nil {
-			return nil
-		}
-	}
-	return nodes
-}
-
 func parseLevel(levelStr string) zerolog.Level {
+	if n, err := strconv.ParseInt(levelStr, 10, 64); err == nil {
+		// zerolog.ParseLevel would take the number as a zerolog level.
+		return parseNumericLevel(n, LevelScaleAuto)
+	}
 	level, err := zerolog.ParseLevel(strings.ToLower(levelStr))
 	if err == nil {
 		return level
 	}
+	// Syslog, Google Cloud, and Serilog levels.
+	switch strings.ToLower(levelStr) {
+	case "default":
+		return zerolog.NoLevel
+	case "warning":
+		return zerolog.WarnLevel
+	case "notice", "information":
+		return zerolog.InfoLevel
+	case "verbose":
+		return zerolog.TraceLevel
+	case "critical", "crit", "alert":
+		return zerolog.FatalLevel
+	case "emergency", "emerg":
+		return zerolog.PanicLevel
+	}
+	if level, ok := parseSlogLevel(levelStr); ok {
+		return level
+	}
 	for _, levelRegex := range levelRegexes {
 		if levelRegex.Regex.MatchString(levelStr) {
 			return levelRegex.Level
@@ -275,7 +801,11 @@ func parseTimestampNode(node *ast.Node) (time.Time, bool) {
 		return time.Time{}, false
 	}
 	if node.Type() == ast.V_NUMBER {
-		if i, err := node.Int64(); err != nil {
+		if i, err := node.Int64(); err == nil {
+			// pino and bunyan use milliseconds since the epoch.
+			if i > 1e11 {
+				return time.UnixMilli(i), true
+			}
 			return time.Unix(i, 0), true
 		}
 	} else if timestampStr, err := node.String(); err == nil {
@@ -290,13 +820,10 @@ func isSonicEOFErr(err error) bool {
 	return eofErrRegex.MatchString(err.Error())
 }
 
-func loggerSetup() error {
-	zerolog.TimestampFunc = func() time.Time {
-		return parsedTime
-	}
+func loggerSetup() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+		Out:        os.Stderr,
+		NoColor:    flags.noColor,
 		TimeFormat: "Jan-02 15:04",
 	}).Level(zerolog.TraceLevel)
-	return nil
 }
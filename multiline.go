@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MultilineRule joins lines to the preceding entry, until a line matches
+// the Start pattern, the same way as Filebeat's multiline setting. This
+// keeps stacktraces, "Caused by:" lines, and dumps together with the entry
+// that wrote them, no matter which processor parsed that entry.
+type MultilineRule struct {
+	Start *regexp.Regexp
+	// MaxLines is the most lines joined to one entry. Further lines are
+	// written as entries of their own. 0 means no limit.
+	MaxLines int
+	// Timeout is how long to wait for more lines before writing the entry,
+	// for when the input goes idle. 0 means to wait until the next entry.
+	Timeout time.Duration
+}
+
+// NewMultilineRule returns nil when the start pattern is empty, meaning
+// that no lines are joined.
+func NewMultilineRule(start string, maxLines int, timeout time.Duration) (*MultilineRule, error) {
+	if start == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(start)
+	if err != nil {
+		return nil, fmt.Errorf("multiline start: %w", err)
+	}
+	return &MultilineRule{Start: re, MaxLines: maxLines, Timeout: timeout}, nil
+}
+
+// joinLine attaches the line to the pending entry, unless it starts a new
+// entry, which a Go panic always does. Reports if it was joined.
+func (r *Relogger) joinLine(b []byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		return false
+	}
+	line := string(containerTimestampRegex.ReplaceAll(b, nil))
+	if r.multiline.Start.MatchString(stripANSI(line)) || goPanicStartRegex.MatchString(line) {
+		return false
+	}
+	if r.multiline.MaxLines > 0 && r.pendingLines >= r.multiline.MaxLines {
+		return false
+	}
+	r.pending.Message += "\n\t" + line
+	r.pending.Raw = append(r.pending.Raw, string(b))
+	r.pendingLines++
+	r.pendingAt = time.Now()
+	return true
+}
+
+// hold keeps the entry as pending, so the following lines can be joined to
+// it, and writes the previously pending entry.
+func (r *Relogger) hold(e *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.holdLocked(e)
+}
+
+func (r *Relogger) holdLocked(e *Entry) {
+	r.flushPendingLocked()
+	r.pending = e
+	r.pendingLines = 0
+	r.pendingAt = time.Now()
+	if r.multiline.Timeout <= 0 {
+		return
+	}
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.multiline.Timeout, r.flushIdle)
+	} else {
+		r.timer.Reset(r.multiline.Timeout)
+	}
+}
+
+// flushIdle writes the pending entry once no lines have been joined to it
+// for the timeout.
+func (r *Relogger) flushIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		return
+	}
+	if idle := time.Since(r.pendingAt); idle < r.multiline.Timeout {
+		r.timer.Reset(r.multiline.Timeout - idle)
+		return
+	}
+	r.flushPendingLocked()
+}
+
+func (r *Relogger) flushPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushPendingLocked()
+}
+
+func (r *Relogger) flushPendingLocked() {
+	if r.pending == nil {
+		return
+	}
+	e := r.pending
+	r.pending = nil
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.write(e)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jilleJr/relog/pkg/config"
+	"github.com/rs/zerolog"
+)
+
+func TestMultilineRule(t *testing.T) {
+	input := `{"level":"error","time":"2022-09-20T17:00:00Z","message":"request failed"}
+java.lang.IllegalStateException: boom
+	at com.example.Service.run(Service.java:42)
+Caused by: java.io.IOException: closed
+WARN slow query
+SELECT *
+FROM users
+INFO done
+`
+	rec := &entryRecorder{}
+	r := NewRelogger(strings.NewReader(input), rec, themes["dark"])
+	r.multiline, _ = NewMultilineRule(`^(\{|WARN|INFO)`, 0, 0)
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(rec.entries))
+	}
+	first := rec.entries[0]
+	if first.Level != zerolog.ErrorLevel {
+		t.Errorf("want level error, got %s", first.Level)
+	}
+	assertEqualString(t, "request failed\n\tjava.lang.IllegalStateException: boom\n\t\tat com.example.Service.run(Service.java:42)\n\tCaused by: java.io.IOException: closed", first.Message, "message")
+	if len(first.Raw) != 4 {
+		t.Errorf("want 4 raw lines, got %d", len(first.Raw))
+	}
+	assertEqualString(t, "slow query\n\tSELECT *\n\tFROM users", rec.entries[1].Message, "message")
+	assertEqualString(t, "done", rec.entries[2].Message, "message")
+}
+
+func TestMultilineRuleMaxLines(t *testing.T) {
+	rec := &entryRecorder{}
+	r := NewRelogger(strings.NewReader("start\na\nb\nc\n"), rec, themes["dark"])
+	r.multiline, _ = NewMultilineRule(`^start`, 2, 0)
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(rec.entries))
+	}
+	assertEqualString(t, "start\n\ta\n\tb", rec.entries[0].Message, "message")
+	assertEqualString(t, "c", rec.entries[1].Message, "message")
+}
+
+func TestMultilineRuleTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+	rec := &entryRecorder{}
+	r := NewRelogger(pr, rec, themes["dark"])
+	r.multiline, _ = NewMultilineRule(`^start`, 0, 10*time.Millisecond)
+	done := make(chan error)
+	go func() { done <- r.RelogAll() }()
+
+	io.WriteString(pw, "start\n  joined\n")
+	time.Sleep(100 * time.Millisecond)
+	r.mu.Lock()
+	written := len(rec.entries)
+	r.mu.Unlock()
+	if written != 1 {
+		t.Errorf("want the entry written after the timeout, got %d entries", written)
+	}
+
+	io.WriteString(pw, "  too late\n")
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(rec.entries))
+	}
+	assertEqualString(t, "start\n\t  joined", rec.entries[0].Message, "message")
+}
+
+func TestApplyFlagsMultiline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("multiline:\n  max-lines: 0\n  timeout: 0s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyFlags(&cfg)
+	if *cfg.Multiline.MaxLines != 0 || *cfg.Multiline.Timeout != 0 {
+		t.Errorf("want zero max-lines and timeout from the config, got %d and %s",
+			*cfg.Multiline.MaxLines, *cfg.Multiline.Timeout)
+	}
+
+	var defaults config.Config
+	applyFlags(&defaults)
+	if *defaults.Multiline.MaxLines != 500 || *defaults.Multiline.Timeout != time.Second {
+		t.Errorf("want default max-lines and timeout, got %d and %s",
+			*defaults.Multiline.MaxLines, *defaults.Multiline.Timeout)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+type NestedMode string
+
+const (
+	// NestedInline prints nested objects and arrays as inline JSON.
+	NestedInline NestedMode = "inline"
+	// NestedTree prints nested objects and arrays as an indented tree below
+	// the entry.
+	NestedTree NestedMode = "tree"
+	// NestedFlatten prints nested objects and arrays as fields with dotted
+	// keys, such as doc.driver.name=...
+	NestedFlatten NestedMode = "flatten"
+)
+
+func ParseNestedMode(s string) (NestedMode, error) {
+	switch mode := NestedMode(s); mode {
+	case NestedInline, NestedTree, NestedFlatten:
+		return mode, nil
+	case "":
+		return NestedInline, nil
+	default:
+		return "", fmt.Errorf("unknown nested mode %q, must be one of: inline, tree, flatten", s)
+	}
+}
+
+type NestedOptions struct {
+	Mode NestedMode
+	// MaxDepth is how many levels of nesting to expand before falling back
+	// to inline JSON. Zero means no limit.
+	MaxDepth int
+	// MaxArrayItems is how many array items to show before collapsing the
+	// rest. Zero means no limit.
+	MaxArrayItems int
+}
+
+// isNested returns true for non-empty objects and arrays.
+func isNested(value any) bool {
+	if arr, ok := value.([]any); ok {
+		return len(arr) > 0
+	}
+	obj, ok := asObject(value)
+	return ok && len(obj) > 0
+}
+
+// children returns the nested fields of an object or array, as well as how
+// many array items were left out.
+func (o NestedOptions) children(value any) ([]Field, int) {
+	if obj, ok := asObject(value); ok {
+		return obj, 0
+	}
+	arr, _ := value.([]any)
+	collapsed := 0
+	if o.MaxArrayItems > 0 && len(arr) > o.MaxArrayItems {
+		collapsed = len(arr) - o.MaxArrayItems
+		arr = arr[:o.MaxArrayItems]
+	}
+	fields := make([]Field, len(arr))
+	for i, item := range arr {
+		fields[i] = Field{Key: strconv.Itoa(i), Value: item}
+	}
+	return fields, collapsed
+}
+
+func (o NestedOptions) canExpand(value any, depth int) bool {
+	if o.MaxDepth > 0 && depth >= o.MaxDepth {
+		return false
+	}
+	return isNested(value)
+}
+
+// flatten replaces nested fields with fields using dotted keys.
+func (o NestedOptions) flatten(fields []Field) []Field {
+	flat := make([]Field, 0, len(fields))
+	for _, field := range fields {
+		flat = o.appendFlattened(flat, field.Key, field.Value, 0)
+	}
+	return flat
+}
+
+func (o NestedOptions) appendFlattened(flat []Field, key string, value any, depth int) []Field {
+	if !o.canExpand(value, depth) {
+		return append(flat, Field{Key: key, Value: value})
+	}
+	children, collapsed := o.children(value)
+	for _, child := range children {
+		flat = o.appendFlattened(flat, key+"."+child.Key, child.Value, depth+1)
+	}
+	if collapsed > 0 {
+		flat = append(flat, Field{Key: key + ".…", Value: fmt.Sprintf("(%d more)", collapsed)})
+	}
+	return flat
+}
+
+// writeTree writes the nested fields as an indented tree, one line per
+// field, in a style similar to YAML.
+func (w *ConsoleWriter) writeTree(buf *bytes.Buffer, fields []Field, indent string, depth int) {
+	for _, field := range fields {
+		w.writeTreeNode(buf, indent, field.Key, field.Value, depth)
+	}
+}
+
+func (w *ConsoleWriter) writeTreeNode(buf *bytes.Buffer, indent, key string, value any, depth int) {
+	buf.WriteString(indent)
+	if key != "" {
+		buf.WriteString(w.Theme.FieldName.Sprint(key + ":"))
+	} else {
+		buf.WriteString(w.Theme.FieldName.Sprint("-"))
+	}
+	if !w.Nested.canExpand(value, depth) {
+		buf.WriteByte(' ')
+		buf.WriteString(formatFieldValue(value))
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	children, collapsed := w.Nested.children(value)
+	_, isArray := value.([]any)
+	for _, child := range children {
+		childKey := child.Key
+		if isArray {
+			childKey = ""
+		}
+		w.writeTreeNode(buf, indent+"  ", childKey, child.Value, depth+1)
+	}
+	if collapsed > 0 {
+		buf.WriteString(indent + "  ")
+		buf.WriteString(w.Theme.Dim.Sprintf("… (%d more)", collapsed))
+		buf.WriteByte('\n')
+	}
+}
+
+// splitNested splits the fields into the ones to print inline and the ones
+// to print as a tree.
+func splitNested(fields []Field) (inline, nested []Field) {
+	for _, field := range fields {
+		if isNested(field.Value) {
+			nested = append(nested, field)
+		} else {
+			inline = append(inline, field)
+		}
+	}
+	return inline, nested
+}
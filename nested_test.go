@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestNestedOptionsFlatten(t *testing.T) {
+	opts := NestedOptions{Mode: NestedFlatten, MaxDepth: 2, MaxArrayItems: 2}
+	fields := opts.flatten([]Field{
+		{Key: "doc", Value: Object{
+			{Key: "driver", Value: Object{{Key: "name", Value: "mongo"}}},
+			{Key: "os", Value: Object{{Key: "type", Value: Object{{Key: "name", Value: "Linux"}}}}},
+		}},
+		{Key: "tags", Value: []any{"a", "b", "c"}},
+	})
+	want := []string{
+		"doc.driver.name=mongo",
+		`doc.os.type={"name":"Linux"}`,
+		"tags.0=a",
+		"tags.1=b",
+		"tags.…=(1 more)",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("want %d fields, got %d: %v", len(want), len(fields), fields)
+	}
+	for i, field := range fields {
+		got := field.Key + "=" + fieldValueString(field.Value)
+		assertEqualString(t, want[i], got, "flattened field")
+	}
+}
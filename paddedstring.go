@@ -2,6 +2,8 @@ package main
 
 import (
 	"strings"
+
+	"github.com/mattn/go-runewidth"
 )
 
 type PaddedString struct {
@@ -19,18 +21,22 @@ func NewPaddedString(historyLen int) *PaddedString {
 	}
 }
 
+// Next pads the value to the widest value seen within the history window.
+// Widths are measured in terminal cells, so wide characters such as CJK and
+// emoji count as two.
 func (p *PaddedString) Next(value string) string {
+	width := runewidth.StringWidth(value)
 	removedWidth := p.history[p.index]
 	p.index = (p.index + 1) % len(p.history)
-	p.history[p.index] = len(value)
-	if len(value) >= p.maxWidth {
-		p.maxWidth = len(value)
+	p.history[p.index] = width
+	if width >= p.maxWidth {
+		p.maxWidth = width
 		return value
 	}
 	if removedWidth < p.maxWidth {
 		p.recalcMaxWidth()
 	}
-	return value + strings.Repeat(" ", p.maxWidth-len(value))
+	return value + strings.Repeat(" ", p.maxWidth-width)
 }
 
 func (p *PaddedString) recalcMaxWidth() {
@@ -14,6 +14,14 @@ func TestPaddedString(t *testing.T) {
 	assertEqualString(t, "f", p.Next("f"), "7th: expect no padding")
 }
 
+func TestPaddedStringWideCharacters(t *testing.T) {
+	p := NewPaddedString(3)
+
+	assertEqualString(t, "日本", p.Next("日本"), "1st: expect no padding")
+	assertEqualString(t, "abc ", p.Next("abc"), "2nd: expect padding to display width")
+	assertEqualString(t, "🚀  ", p.Next("🚀"), "3rd: expect emoji to count as two cells")
+}
+
 func assertEqualString(t *testing.T, want, got, msg string) {
 	t.Helper()
 	if got != want {
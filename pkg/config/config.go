@@ -1,18 +1,119 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 type Config struct {
-	Patterns []Pattern
+	Theme       string       `yaml:"theme"`
+	Colors      Colors       `yaml:"colors"`
+	FieldColors []FieldColor `yaml:"field-colors"`
+	Nested      Nested       `yaml:"nested"`
+	Fields      Fields       `yaml:"fields"`
+	Columns     Columns      `yaml:"columns"`
+	Layout      Layout       `yaml:"layout"`
+	Hyperlinks  Hyperlinks   `yaml:"hyperlinks"`
+	Keys        Keys         `yaml:"keys"`
+	// LevelScale is the scale of numeric levels, such as "pino" for 30
+	// meaning info. Defaults to picking it automatically.
+	LevelScale string    `yaml:"level-scale"`
+	Multiline  Multiline `yaml:"multiline"`
+	Patterns   []Pattern `yaml:"patterns"`
+	// Profiles are named sets of settings that are applied on top of the
+	// rest of the config when selected with --profile.
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	Fields Fields `yaml:"fields"`
+}
+
+type Colors struct {
+	Timestamp string            `yaml:"timestamp"`
+	Caller    string            `yaml:"caller"`
+	FieldName string            `yaml:"field-name"`
+	Error     string            `yaml:"error"`
+	Match     string            `yaml:"match"`
+	Levels    map[string]string `yaml:"levels"`
+}
+
+type FieldColor struct {
+	Rule  string `yaml:"rule"`
+	Color string `yaml:"color"`
+}
+
+type Nested struct {
+	Mode          string `yaml:"mode"`
+	MaxDepth      int    `yaml:"max-depth"`
+	MaxArrayItems int    `yaml:"max-array-items"`
+}
+
+type Fields struct {
+	Priority  []string `yaml:"priority"`
+	Order     string   `yaml:"order"`
+	Include   []string `yaml:"include"`
+	Exclude   []string `yaml:"exclude"`
+	HideEmpty bool     `yaml:"hide-empty"`
+}
+
+type Columns struct {
+	Caller *bool    `yaml:"caller"`
+	Fields []string `yaml:"fields"`
+	Window int      `yaml:"window"`
+}
+
+type Layout struct {
+	Mode  string `yaml:"mode"`
+	Width int    `yaml:"width"`
+}
+
+type Hyperlinks struct {
+	Enabled  bool          `yaml:"enabled"`
+	Root     string        `yaml:"root"`
+	Template string        `yaml:"template"`
+	Rewrites []PathRewrite `yaml:"rewrites"`
+}
+
+type PathRewrite struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Keys holds the candidate dotted paths of the canonical fields in JSON
+// logs, in order of priority. Unset lists use the defaults.
+type Keys struct {
+	Level      []string `yaml:"level"`
+	Message    []string `yaml:"message"`
+	Time       []string `yaml:"time"`
+	Caller     []string `yaml:"caller"`
+	Stacktrace []string `yaml:"stacktrace"`
+}
+
+// Multiline joins the lines that do not match the Start regex to the
+// preceding entry. Lines are not joined when Start is unset. MaxLines and
+// Timeout use the defaults when unset, where 0 means no limit.
+type Multiline struct {
+	Start    string         `yaml:"start"`
+	MaxLines *int           `yaml:"max-lines"`
+	Timeout  *time.Duration `yaml:"timeout"`
 }
 
 type Pattern struct {
-	LeadingTimestamp *PatternLeadingTimestamp
-	JSON             *PatternJSON
-	LogFmt           *PatternLogFmt
+	LeadingTimestamp *PatternLeadingTimestamp `yaml:"leading-timestamp"`
+	JSON             *PatternJSON             `yaml:"json"`
+	LogFmt           *PatternLogFmt           `yaml:"logfmt"`
 }
 
 type PatternLeadingTimestamp struct {
-	Layouts []string
-	Trim    bool
+	Layouts []string `yaml:"layouts"`
+	Trim    bool     `yaml:"trim"`
 }
 
 type PatternJSON struct {
@@ -20,3 +121,58 @@ type PatternJSON struct {
 
 type PatternLogFmt struct {
 }
+
+// ApplyProfile applies the settings of the named profile on top of the
+// config.
+func (c *Config) ApplyProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if profile.Fields.Priority != nil {
+		c.Fields.Priority = profile.Fields.Priority
+	}
+	if profile.Fields.Order != "" {
+		c.Fields.Order = profile.Fields.Order
+	}
+	if profile.Fields.Include != nil {
+		c.Fields.Include = profile.Fields.Include
+	}
+	if profile.Fields.Exclude != nil {
+		c.Fields.Exclude = profile.Fields.Exclude
+	}
+	if profile.Fields.HideEmpty {
+		c.Fields.HideEmpty = true
+	}
+	return nil
+}
+
+// DefaultPath returns the path to the config file used when none is given,
+// e.g ~/.config/relog/config.yaml on Linux.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "relog", "config.yaml")
+}
+
+// Load reads the config file at the given path. A missing file is not an
+// error when optional is true, and results in an empty config.
+func Load(path string, optional bool) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
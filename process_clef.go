@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic/ast"
+)
+
+// clefEntry holds the parts of a Serilog Compact Log Event Format (CLEF)
+// log line that are rendered differently than other JSON logs.
+// https://github.com/serilog/serilog-formatting-compact#format-details
+type clefEntry struct {
+	// message is rendered from the message template, for when the line has
+	// no rendered message, with the byte offsets of the property values.
+	message    string
+	properties [][2]int
+	fields     []Field
+	// ignore holds the paths of the processed nodes.
+	ignore [][]string
+}
+
+var clefRenamedKeys = []struct{ from, to string }{
+	{"@i", "event_id"},
+	{"@tr", "trace"},
+	{"@sp", "span"},
+}
+
+// parseCLEF returns the CLEF specific parts of the line, if it is in that
+// format, as identified by the "@t" field together with "@m" or "@mt".
+func parseCLEF(root ast.Node) (clefEntry, bool) {
+	var clef clefEntry
+	if !root.Get("@t").Exists() || (!root.Get("@m").Exists() && !root.Get("@mt").Exists()) {
+		return clef, false
+	}
+	for _, rename := range clefRenamedKeys {
+		if node := root.Get(rename.from); node.Exists() {
+			clef.fields = append(clef.fields, Field{Key: rename.to, Value: nodeValue(node)})
+			clef.ignore = append(clef.ignore, []string{rename.from})
+		}
+	}
+	// Renderings of the formatted properties, that are only needed when
+	// rendering the template with the original formatting.
+	clef.ignore = append(clef.ignore, []string{"@r"})
+
+	template, err := root.Get("@mt").String()
+	if err != nil {
+		return clef, true
+	}
+	clef.ignore = append(clef.ignore, []string{"@mt"})
+	if root.Get("@m").Exists() {
+		return clef, true
+	}
+	clef.message, clef.properties = renderMessageTemplate(template, func(name, format string) (string, bool) {
+		node := root.Get(name)
+		if !node.Exists() {
+			return "", false
+		}
+		clef.ignore = append(clef.ignore, []string{name})
+		value := nodeValue(node)
+		str := fieldValueString(value)
+		if _, isString := value.(string); isString && format != "l" {
+			str = strconv.Quote(str)
+		}
+		return str, true
+	})
+	return clef, true
+}
+
+// renderMessageTemplate replaces the {Property} holes in a Serilog message
+// template, including ones with the @ and $ operators, alignment, and
+// format, such as {@User} and {Elapsed,8:0.00}. Holes without a value are
+// left as they are, and {{ and }} are unescaped. Also returns the start and
+// end byte offsets of the inserted values.
+func renderMessageTemplate(template string, lookup func(name, format string) (string, bool)) (string, [][2]int) {
+	var sb strings.Builder
+	var spans [][2]int
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c == '}' && strings.HasPrefix(template[i:], "}}") {
+			sb.WriteByte('}')
+			i++
+			continue
+		}
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+		if strings.HasPrefix(template[i:], "{{") {
+			sb.WriteByte('{')
+			i++
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			sb.WriteString(template[i:])
+			break
+		}
+		hole := template[i+1 : i+end]
+		name := strings.TrimLeft(hole, "@$")
+		var format string
+		if idx := strings.IndexByte(name, ':'); idx >= 0 {
+			name, format = name[:idx], name[idx+1:]
+		}
+		if idx := strings.IndexByte(name, ','); idx >= 0 {
+			name = name[:idx]
+		}
+		if value, ok := lookup(name, format); ok {
+			spans = append(spans, [2]int{sb.Len(), sb.Len() + len(value)})
+			sb.WriteString(value)
+		} else {
+			sb.WriteString(template[i : i+end+1])
+		}
+		i += end
+	}
+	return sb.String(), spans
+}
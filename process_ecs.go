@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic/ast"
+)
+
+// ecsEntry holds the parts of an Elastic Common Schema (ECS) log line that
+// are rendered differently than other JSON logs.
+// https://www.elastic.co/guide/en/ecs-logging/overview/current/intro.html
+type ecsEntry struct {
+	caller     string
+	stacktrace string
+	// fields are the correlation fields, printed before the other fields.
+	fields []Field
+	// ignore holds the paths of the processed nodes.
+	ignore [][]string
+}
+
+var ecsCorrelationPaths = []string{"trace.id", "span.id", "transaction.id"}
+
+// parseECS returns the ECS specific parts of the line, if it is in the ECS
+// format, as identified by the "ecs.version" field.
+func parseECS(root ast.Node) (ecsEntry, bool) {
+	var ecs ecsEntry
+	versionPath, versionNode := findWithAnyPath(root, "ecs.version")
+	if versionNode == nil {
+		return ecs, false
+	}
+	ecs.ignore = append(ecs.ignore, versionPath)
+
+	str := func(path string) string {
+		keys, node := findWithAnyPath(root, path)
+		if node == nil {
+			return ""
+		}
+		s, err := node.String()
+		if err != nil {
+			return ""
+		}
+		ecs.ignore = append(ecs.ignore, keys)
+		return s
+	}
+
+	if logger := str("log.logger"); logger != "" {
+		ecs.caller = logger
+	} else if file := str("log.origin.file.name"); file != "" {
+		ecs.caller = file
+		if line := str("log.origin.file.line"); line != "" {
+			ecs.caller = fmt.Sprintf("%s:%s", file, line)
+		}
+	}
+
+	if service := str("service.name"); service != "" {
+		ecs.fields = append(ecs.fields, Field{Key: "source", Value: service})
+	}
+	for _, path := range ecsCorrelationPaths {
+		if id := str(path); id != "" {
+			ecs.fields = append(ecs.fields, Field{Key: path, Value: id})
+		}
+	}
+
+	errType := str("error.type")
+	errMessage := str("error.message")
+	stack := str("error.stack_trace")
+	var sb strings.Builder
+	if errType != "" || errMessage != "" {
+		summary := strings.TrimPrefix(errType+": "+errMessage, ": ")
+		summary = strings.TrimSuffix(summary, ": ")
+		// Java stacktraces already start with the type and message.
+		if !strings.HasPrefix(stack, summary) {
+			sb.WriteString(summary)
+			if stack != "" {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	sb.WriteString(stack)
+	ecs.stacktrace = strings.ReplaceAll(strings.TrimRight(sb.String(), "\n"), "\n", "\n\t")
+	return ecs, true
+}
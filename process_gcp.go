@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/bytedance/sonic/ast"
+)
+
+// gcpEntry holds the parts of a Google Cloud structured log line that are
+// rendered differently than other JSON logs.
+// https://cloud.google.com/logging/docs/structured-logging
+type gcpEntry struct {
+	caller string
+	// fields replace the processed nodes, and are printed before the other
+	// fields.
+	fields []Field
+	// ignore holds the paths of the processed nodes.
+	ignore [][]string
+}
+
+const gcpKeyPrefix = "logging.googleapis.com/"
+
+// parseGCP returns the Google Cloud specific parts of the line, if it has
+// any of the special fields. The keys without the "logging.googleapis.com/"
+// prefix, such as "trace", are common in other logs too, so they are only
+// used for LogEntry objects, or lines with the "severity" level key.
+func parseGCP(root ast.Node, isLogEntry bool) (gcpEntry, bool) {
+	var gcp gcpEntry
+	found := false
+	bare := isLogEntry || root.Get("severity").Exists()
+	paths := func(key string) []string {
+		if bare {
+			return []string{gcpKeyPrefix + key, key}
+		}
+		return []string{gcpKeyPrefix + key}
+	}
+
+	if keys, node := findWithAnyPath(root, paths("sourceLocation")...); node != nil && node.Type() == ast.V_OBJECT {
+		file, _ := node.Get("file").String()
+		line, _ := node.Get("line").String()
+		function, _ := node.Get("function").String()
+		switch {
+		case file != "" && line != "":
+			gcp.caller = file + ":" + line
+		case file != "":
+			gcp.caller = file
+		}
+		if function != "" {
+			gcp.caller = strings.TrimSpace(gcp.caller + " (" + function + ")")
+		}
+		gcp.ignore = append(gcp.ignore, keys)
+		found = true
+	}
+
+	if keys, node := findWithAnyPath(root, paths("trace")...); node != nil {
+		if trace, err := node.String(); err == nil && strings.Contains(trace, "/traces/") {
+			// Shortened from "projects/my-project/traces/0123abc".
+			gcp.fields = append(gcp.fields, Field{Key: "trace", Value: trace[strings.LastIndex(trace, "/")+1:]})
+			gcp.ignore = append(gcp.ignore, keys)
+			found = true
+		}
+	}
+	if keys, node := findWithAnyPath(root, paths("spanId")...); node != nil && found {
+		if span, err := node.String(); err == nil {
+			gcp.fields = append(gcp.fields, Field{Key: "span", Value: span})
+			gcp.ignore = append(gcp.ignore, keys)
+		}
+	}
+
+	if keys, node := findWithAnyPath(root, "httpRequest"); bare && node != nil && node.Type() == ast.V_OBJECT {
+		if request := formatHTTPRequest(node); request != "" {
+			gcp.fields = append(gcp.fields, Field{Key: "httpRequest", Value: request})
+			gcp.ignore = append(gcp.ignore, keys)
+			found = true
+		}
+	}
+	return gcp, found
+}
+
+// formatHTTPRequest returns a Google Cloud HttpRequest object on one line,
+// such as "GET https://example.com/ 200 12ms".
+func formatHTTPRequest(node *ast.Node) string {
+	var parts []string
+	for _, key := range []string{"requestMethod", "requestUrl", "status", "latency"} {
+		if value, err := node.Get(key).String(); err == nil && value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// gcpLogEntryNoise are the keys of a Google Cloud LogEntry that are left out
+// when unwrapping its payload.
+var gcpLogEntryNoise = []string{"insertId", "logName", "receiveTimestamp", "jsonPayload", "textPayload"}
+
+// unwrapGCPLogEntry turns a Google Cloud LogEntry, as written by
+// "gcloud logging read --format=json", into a flat object with the fields
+// of its payload alongside the severity, timestamp, and other fields.
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry
+func unwrapGCPLogEntry(root ast.Node) (ast.Node, bool) {
+	if !root.Get("logName").Exists() || !root.Get("insertId").Exists() {
+		return root, false
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key string, node *ast.Node) {
+		raw, err := node.Raw()
+		if err != nil {
+			return
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(key)
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.WriteString(raw)
+	}
+	if payload := root.Get("textPayload"); payload.Exists() {
+		write("message", payload)
+	}
+	if payload := root.Get("jsonPayload"); payload.Exists() {
+		payload.ForEach(func(path ast.Sequence, node *ast.Node) bool {
+			if path.Key != nil {
+				write(*path.Key, node)
+			}
+			return true
+		})
+	}
+	root.ForEach(func(path ast.Sequence, node *ast.Node) bool {
+		if path.Key == nil {
+			return true
+		}
+		for _, noise := range gcpLogEntryNoise {
+			if *path.Key == noise {
+				return true
+			}
+		}
+		write(*path.Key, node)
+		return true
+	})
+	buf.WriteByte('}')
+	unwrapped, err := sonic.Get(buf.Bytes())
+	if err != nil {
+		return root, false
+	}
+	return unwrapped, true
+}
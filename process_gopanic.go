@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// GoroutineDump holds the stacks written by the Go runtime on a panic,
+// fatal error, or SIGQUIT, where goroutines with the same stack are grouped
+// together.
+type GoroutineDump struct {
+	Groups []GoroutineGroup
+}
+
+// GoroutineGroup is one or more goroutines that have the same state and
+// stack, such as a pool of workers waiting on the same channel.
+type GoroutineGroup struct {
+	IDs    []string
+	State  string
+	Frames []StackFrame
+}
+
+type StackFrame struct {
+	// Func is the call, such as "main.divide(0x5, 0x0)", or the go
+	// statement, such as "created by main.main in goroutine 1".
+	Func string
+	// File is the file and line, such as "/app/main.go:12".
+	File string
+	// FirstParty is set for the frames of the module that is debugged.
+	FirstParty bool
+	// Runtime is set for the frames of the Go runtime, that are collapsed.
+	Runtime bool
+}
+
+var (
+	goPanicStartRegex  = regexp.MustCompile(`^(?:panic: |fatal error: |SIGQUIT: |SIGABRT: )`)
+	goPanicHeaderRegex = regexp.MustCompile(`^(?:\tpanic: |\[signal |PC=|fatal error: |\s*\[recovered\])`)
+	goroutineRegex     = regexp.MustCompile(`^goroutine (\d+)(?: gp=\S+ m=\S+(?: mp=\S+)?)? \[(.*)\]:$`)
+	goFuncRegex        = regexp.MustCompile(`^(?:created by \S+(?: in goroutine \d+)?|[^\s(]+\(.*\)|\.\.\.additional frames elided\.\.\.)$`)
+	goFileRegex        = regexp.MustCompile(`^\t(\S+:\d+)(?: \+0x[0-9a-f]+)?(?: fp=.*)?$`)
+	goRegisterRegex    = regexp.MustCompile(`^\w+\s+0x[0-9a-f]+$`)
+	goWaitTimeRegex    = regexp.MustCompile(`, \d+ minutes`)
+)
+
+// goPanic is a goroutine dump that is being read, line by line.
+type goPanic struct {
+	message    []string
+	time       time.Time
+	goroutines []GoroutineGroup
+	raw        []string
+	// lastLine is when the last line of the dump was read.
+	lastLine time.Time
+}
+
+// processLineGoPanic starts reading a goroutine dump, which continues until
+// a line that is not part of it, the end of the input, or the input going
+// idle for goPanicIdle.
+func (r *Relogger) processLineGoPanic(b []byte) bool {
+	if !goPanicStartRegex.Match(b) {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.goPanic = &goPanic{
+		message:  []string{string(b)},
+		time:     parsedTime,
+		raw:      r.raw,
+		lastLine: time.Now(),
+	}
+	r.raw = nil
+	if r.goPanicIdle <= 0 {
+		return true
+	}
+	if r.goPanicTimer == nil {
+		r.goPanicTimer = time.AfterFunc(r.goPanicIdle, r.flushGoPanicIdle)
+	} else {
+		r.goPanicTimer.Reset(r.goPanicIdle)
+	}
+	return true
+}
+
+// addGoPanicLine adds the line to the goroutine dump being read, if any.
+// The dump is written when the line is not part of it.
+func (r *Relogger) addGoPanicLine(b []byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.goPanic == nil {
+		return false
+	}
+	if r.goPanic.addLine(string(b)) {
+		r.goPanic.lastLine = time.Now()
+		return true
+	}
+	r.flushGoPanicLocked()
+	return false
+}
+
+// addLine adds the line to the dump, unless the dump has ended.
+func (p *goPanic) addLine(raw string) bool {
+	line := containerTimestampRegex.ReplaceAllString(raw, "")
+	switch {
+	case line == "":
+	case len(p.goroutines) == 0 && goPanicHeaderRegex.MatchString(line):
+		p.message = append(p.message, strings.TrimSpace(line))
+	case goRegisterRegex.MatchString(line):
+		// The registers of a SIGQUIT dump are only kept in the raw lines.
+	case line == "runtime stack:":
+		p.goroutines = append(p.goroutines, GoroutineGroup{State: "runtime stack"})
+	case goroutineRegex.MatchString(line):
+		groups := goroutineRegex.FindStringSubmatch(line)
+		p.goroutines = append(p.goroutines, GoroutineGroup{
+			IDs:   []string{groups[1]},
+			State: goWaitTimeRegex.ReplaceAllString(groups[2], ""),
+		})
+	case len(p.goroutines) > 0 && goFuncRegex.MatchString(line):
+		g := &p.goroutines[len(p.goroutines)-1]
+		g.Frames = append(g.Frames, StackFrame{Func: line})
+	case len(p.goroutines) > 0 && goFileRegex.MatchString(line):
+		g := &p.goroutines[len(p.goroutines)-1]
+		if len(g.Frames) == 0 {
+			return false
+		}
+		g.Frames[len(g.Frames)-1].File = goFileRegex.FindStringSubmatch(line)[1]
+	default:
+		return false
+	}
+	p.raw = append(p.raw, raw)
+	return true
+}
+
+// flushGoPanicIdle writes the goroutine dump being read once no lines have
+// been added to it for goPanicIdle, such as when a crashed service is
+// followed with "kubectl logs -f".
+func (r *Relogger) flushGoPanicIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.goPanic == nil {
+		return
+	}
+	if idle := time.Since(r.goPanic.lastLine); idle < r.goPanicIdle {
+		r.goPanicTimer.Reset(r.goPanicIdle - idle)
+		return
+	}
+	r.flushGoPanicLocked()
+}
+
+func (r *Relogger) flushGoPanic() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushGoPanicLocked()
+}
+
+// flushGoPanicLocked writes the goroutine dump being read as one entry.
+func (r *Relogger) flushGoPanicLocked() {
+	p := r.goPanic
+	if p == nil {
+		return
+	}
+	r.goPanic = nil
+	if r.goPanicTimer != nil {
+		r.goPanicTimer.Stop()
+	}
+	dump := groupGoroutines(p.goroutines)
+	for i := range dump.Groups {
+		for j := range dump.Groups[i].Frames {
+			f := &dump.Groups[i].Frames[j]
+			pkg := goFuncPackage(f.Func)
+			f.Runtime = pkg == "runtime" || strings.HasPrefix(pkg, "runtime/") ||
+				strings.HasPrefix(pkg, "internal/runtime/") ||
+				strings.Contains(f.File, "/src/runtime/")
+			f.FirstParty = pkg == "main" || (r.goModule != "" &&
+				(pkg == r.goModule || strings.HasPrefix(pkg, r.goModule+"/")))
+		}
+	}
+
+	// The entry is not written with Msg, as that would use the state of
+	// the line being read, which the idle timer must not touch.
+	e := &Entry{
+		Level:   zerolog.FatalLevel,
+		Time:    p.time,
+		Caller:  dump.caller(),
+		Message: strings.Join(p.message, "\n\t"),
+		Raw:     p.raw,
+		Dump:    dump,
+	}
+	if r.multiline != nil {
+		r.holdLocked(e)
+	} else {
+		r.write(e)
+	}
+}
+
+// groupGoroutines merges the goroutines that have the same state and stack,
+// keeping the order they were first seen in.
+func groupGoroutines(goroutines []GoroutineGroup) *GoroutineDump {
+	dump := &GoroutineDump{}
+	index := make(map[string]int)
+	for _, g := range goroutines {
+		var key strings.Builder
+		key.WriteString(g.State)
+		for _, f := range g.Frames {
+			// The arguments differ between goroutines with the same stack.
+			key.WriteString("\n" + goFuncName(f.Func) + " " + f.File)
+		}
+		if i, ok := index[key.String()]; ok {
+			dump.Groups[i].IDs = append(dump.Groups[i].IDs, g.IDs...)
+			continue
+		}
+		index[key.String()] = len(dump.Groups)
+		dump.Groups = append(dump.Groups, g)
+	}
+	return dump
+}
+
+// caller returns where the first goroutine, that is the one that panicked,
+// was in the first-party code, or else outside of the runtime.
+func (d *GoroutineDump) caller() string {
+	if len(d.Groups) == 0 {
+		return ""
+	}
+	frames := d.Groups[0].Frames
+	for _, f := range frames {
+		if f.FirstParty && f.File != "" {
+			return f.File
+		}
+	}
+	for _, f := range frames {
+		if !f.Runtime && f.File != "" {
+			return f.File
+		}
+	}
+	return ""
+}
+
+// Header returns the line that the frames of the group are listed under,
+// such as "goroutines 7, 8, 9 [chan receive]:".
+func (g GoroutineGroup) Header() string {
+	switch len(g.IDs) {
+	case 0:
+		return g.State + ":"
+	case 1:
+		return "goroutine " + g.IDs[0] + " [" + g.State + "]:"
+	default:
+		return "goroutines " + strings.Join(g.IDs, ", ") + " [" + g.State + "]:"
+	}
+}
+
+// Goroutines returns the number of goroutines in all groups.
+func (d *GoroutineDump) Goroutines() int {
+	var n int
+	for _, g := range d.Groups {
+		n += len(g.IDs)
+	}
+	return n
+}
+
+// runtimeRuns splits the frames into runs of consecutive runtime frames,
+// and single other frames.
+func runtimeRuns(frames []StackFrame) [][]StackFrame {
+	var runs [][]StackFrame
+	for i, f := range frames {
+		if f.Runtime && i > 0 && frames[i-1].Runtime {
+			runs[len(runs)-1] = append(runs[len(runs)-1], f)
+			continue
+		}
+		runs = append(runs, []StackFrame{f})
+	}
+	return runs
+}
+
+// goFuncName returns the function without its arguments, such as
+// "main.(*T).Run" for "main.(*T).Run(0xc000010000)".
+func goFuncName(fn string) string {
+	for i := 0; i < len(fn); i++ {
+		// Method receivers, such as "(*T)", come right after a dot.
+		if fn[i] == '(' && (i == 0 || fn[i-1] != '.') {
+			return fn[:i]
+		}
+	}
+	return fn
+}
+
+// goFuncPackage returns the import path of the package of the function,
+// such as "github.com/x/y/pkg" for "github.com/x/y/pkg.(*T).Run(...)".
+func goFuncPackage(fn string) string {
+	fn = goFuncName(strings.TrimPrefix(fn, "created by "))
+	fn, _, _ = strings.Cut(fn, " in goroutine ")
+	slash := strings.LastIndexByte(fn, '/')
+	dot := strings.IndexByte(fn[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return fn[:slash+1+dot]
+}
+
+// detectGoModule returns the module path from the go.mod file in the
+// working directory, if any.
+func detectGoModule() string {
+	f, err := os.Open("go.mod")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const goPanicInput = `starting
+panic: runtime error: integer divide by zero
+[signal SIGFPE: floating-point exception code=0x1 addr=0x49a1ca pc=0x49a1ca]
+
+goroutine 1 [running]:
+panic({0x55b1a8?, 0x56d340?})
+	/usr/local/go/src/runtime/panic.go:878 +0x159
+example.com/app/calc.divide(...)
+	/app/calc/calc.go:10
+main.main()
+	/app/main.go:19 +0x135
+
+goroutine 6 [chan receive, 2 minutes]:
+github.com/other/pool.(*Pool).worker(0xc000010000)
+	/go/pkg/mod/github.com/other/pool@v1.0.0/pool.go:8 +0x25
+created by main.main in goroutine 1
+	/app/main.go:15 +0x37
+
+goroutine 7 [chan receive]:
+github.com/other/pool.(*Pool).worker(0xc000010008)
+	/go/pkg/mod/github.com/other/pool@v1.0.0/pool.go:8 +0x25
+created by main.main in goroutine 1
+	/app/main.go:15 +0x37
+exit status 2
+`
+
+func TestProcessGoPanic(t *testing.T) {
+	rec := &entryRecorder{}
+	r := NewRelogger(strings.NewReader(goPanicInput), rec, themes["dark"])
+	r.goModule = "example.com/app"
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(rec.entries))
+	}
+	assertEqualString(t, "exit status 2", rec.entries[2].Message, "message after dump")
+
+	e := rec.entries[1]
+	if e.Level != zerolog.FatalLevel {
+		t.Errorf("want level fatal, got %s", e.Level)
+	}
+	assertEqualString(t, "panic: runtime error: integer divide by zero\n\t[signal SIGFPE: floating-point exception code=0x1 addr=0x49a1ca pc=0x49a1ca]", e.Message, "message")
+	assertEqualString(t, "/app/calc/calc.go:10", e.Caller, "caller")
+	if len(e.Raw) != 22 {
+		t.Errorf("want 22 raw lines, got %d", len(e.Raw))
+	}
+	if e.Dump == nil || len(e.Dump.Groups) != 2 {
+		t.Fatalf("want 2 goroutine groups, got %+v", e.Dump)
+	}
+	if n := e.Dump.Goroutines(); n != 3 {
+		t.Errorf("want 3 goroutines, got %d", n)
+	}
+	assertEqualString(t, "goroutines 6, 7 [chan receive]:", e.Dump.Groups[1].Header(), "header")
+
+	frames := e.Dump.Groups[0].Frames
+	want := []struct{ firstParty, runtime bool }{
+		{false, true},
+		{true, false},
+		{true, false},
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("want %d frames, got %d", len(want), len(frames))
+	}
+	for i, w := range want {
+		if frames[i].FirstParty != w.firstParty || frames[i].Runtime != w.runtime {
+			t.Errorf("frame %d %q: want first-party=%t runtime=%t, got %t %t",
+				i, frames[i].Func, w.firstParty, w.runtime, frames[i].FirstParty, frames[i].Runtime)
+		}
+	}
+	if e.Dump.Groups[1].Frames[0].FirstParty {
+		t.Errorf("want third-party frame, got first-party: %q", e.Dump.Groups[1].Frames[0].Func)
+	}
+}
+
+func TestGoFuncPackage(t *testing.T) {
+	tests := []struct {
+		fn   string
+		want string
+	}{
+		{"main.main()", "main"},
+		{"main.(*Server).Run(0xc000010000)", "main"},
+		{"github.com/x/y/pkg.(*T).Run(...)", "github.com/x/y/pkg"},
+		{"github.com/x/y.Map[...](0x1)", "github.com/x/y"},
+		{"created by net/http.(*Server).Serve in goroutine 1", "net/http"},
+		{"panic({0x55b1a8?, 0x56d340?})", ""},
+	}
+	for _, tc := range tests {
+		assertEqualString(t, tc.want, goFuncPackage(tc.fn), tc.fn)
+	}
+}
+
+func TestProcessGoPanicMultiline(t *testing.T) {
+	input := "2022-09-20 starting\n" +
+		"panic: boom\n\n" +
+		"goroutine 1 [running]:\n" +
+		"main.main()\n" +
+		"\t/app/main.go:19 +0x135\n" +
+		"2022-09-20 restarted\n"
+	rec := &entryRecorder{}
+	r := NewRelogger(strings.NewReader(input), rec, themes["dark"])
+	r.multiline, _ = NewMultilineRule(`^\d{4}-\d{2}-\d{2}`, 0, 0)
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(rec.entries))
+	}
+	e := rec.entries[1]
+	if e.Level != zerolog.FatalLevel || e.Dump == nil {
+		t.Fatalf("want a fatal goroutine dump, got level %s and dump %+v", e.Level, e.Dump)
+	}
+	assertEqualString(t, "panic: boom", e.Message, "message")
+	assertEqualString(t, "/app/main.go:19", e.Caller, "caller")
+}
+
+func TestProcessGoPanicIdle(t *testing.T) {
+	pr, pw := io.Pipe()
+	rec := &entryRecorder{}
+	r := NewRelogger(pr, rec, themes["dark"])
+	r.goPanicIdle = 10 * time.Millisecond
+	done := make(chan error)
+	go func() { done <- r.RelogAll() }()
+
+	io.WriteString(pw, "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:19 +0x135\n")
+	time.Sleep(100 * time.Millisecond)
+	r.mu.Lock()
+	written := len(rec.entries)
+	r.mu.Unlock()
+	if written != 1 {
+		t.Errorf("want the dump written after the input went idle, got %d entries", written)
+	}
+
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(rec.entries))
+	}
+	if rec.entries[0].Dump == nil || len(rec.entries[0].Dump.Groups) != 1 {
+		t.Errorf("want 1 goroutine group, got %+v", rec.entries[0].Dump)
+	}
+}
@@ -7,11 +7,56 @@ import (
 
 	"github.com/bytedance/sonic"
 	"github.com/bytedance/sonic/ast"
+	"github.com/jilleJr/relog/pkg/config"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"gopkg.in/typ.v4/slices"
 )
 
+// stacktraceHeader separates the message from the stacktrace that is
+// appended to it.
+const stacktraceHeader = "\n\tSTACKTRACE\n\t==========\n\t"
+
+// JSONKeys holds the candidate paths of the canonical fields in JSON logs,
+// in order of priority. The paths are dotted, and match both nested objects
+// and flattened keys, such as {"log":{"level":"info"}} and
+// {"log.level":"info"}.
+type JSONKeys struct {
+	Level      []string
+	Message    []string
+	Time       []string
+	Caller     []string
+	Stacktrace []string
+}
+
+var defaultJSONKeys = JSONKeys{
+	Level:      []string{"level", "lvl", "severity", "log.level", "severityText", "level_value", "severityNumber", "@l"},
+	Message:    []string{"message", "msg", "@m"},
+	Time:       []string{"time", "timestamp", "@timestamp", "ts", "datetime", "@t"},
+	Caller:     []string{"caller", "logger_name", "logger", "log.logger", "SourceContext"},
+	Stacktrace: []string{"stacktrace", "stack_trace", "stack", "error.stack_trace", "@x"},
+}
+
+// NewJSONKeys returns the default keys, with the lists from the config
+// replacing the defaults.
+func NewJSONKeys(keys config.Keys) JSONKeys {
+	result := defaultJSONKeys
+	overrides := []struct {
+		paths []string
+		dst   *[]string
+	}{
+		{keys.Level, &result.Level},
+		{keys.Message, &result.Message},
+		{keys.Time, &result.Time},
+		{keys.Caller, &result.Caller},
+		{keys.Stacktrace, &result.Stacktrace},
+	}
+	for _, o := range overrides {
+		if o.paths != nil {
+			*o.dst = o.paths
+		}
+	}
+	return result
+}
+
 func (r *Relogger) processLineJson(b []byte) bool {
 	if r.buf.Len() > 0 {
 		r.buf.Write(b)
@@ -29,42 +74,72 @@ func (r *Relogger) processLineJson(b []byte) bool {
 		return false
 	}
 	r.buf.Reset()
-	if root.Type() != ast.V_OBJECT {
+	switch root.Type() {
+	case ast.V_OBJECT:
+		r.processJSONObject(root)
+		return true
+	case ast.V_ARRAY:
+		// Such as from "gcloud logging read --format=json".
+		children, _ := root.ArrayUseNode()
+		if len(children) == 0 {
+			return false
+		}
+		for _, child := range children {
+			if child.Type() != ast.V_OBJECT {
+				return false
+			}
+		}
+		// Each entry gets the raw lines of the whole array, as the elements
+		// are not split by lines when the array is written on one.
+		// Elements without a timestamp must not get the one of the element
+		// before them, only that of the container runtime, if any.
+		raw, lineTime := r.raw, parsedTime
+		for _, child := range children {
+			r.raw, parsedTime = raw, lineTime
+			r.processJSONObject(child)
+		}
+		return true
+	default:
 		return false
 	}
+}
+
+func (r *Relogger) processJSONObject(root ast.Node) {
+	root, isGCPLogEntry := unwrapGCPLogEntry(root)
 	var (
 		level            = zerolog.NoLevel
 		message          = ""
 		caller           = ""
 		isMongoDBLogging = false
-		ignoreNodes      []string
+		ignoreNodes      [][]string
 	)
-	levelNodeName, levelNode := findWithAnyName(root, "level", "lvl", "severity", "log.level")
+	levelNodePath, levelNode := findWithAnyPath(root, r.jsonKeys.Level...)
 	if levelNode != nil {
 		if levelStr, err := levelNode.String(); err == nil {
-			level = parseLevel(levelStr)
-			ignoreNodes = append(ignoreNodes, levelNodeName)
+			scale := levelScaleForKey(strings.Join(levelNodePath, "."), r.levelScale)
+			level = parseLevelWithScale(levelStr, scale)
+			ignoreNodes = append(ignoreNodes, levelNodePath)
 		}
 	} else {
 		// MongoDB styled logging
 		// https://www.mongodb.com/docs/manual/reference/log-messages/#std-label-log-severity-levels
-		levelNodeName, levelNode = findWithAnyName(root, "s")
+		levelNodeName, levelNode := findWithAnyName(root, "s")
 		if levelNode != nil {
 			if levelStr, err := levelNode.String(); err == nil {
 				if lvl, ok := parseMongoDBLevel(levelStr); ok {
 					level = lvl
 					isMongoDBLogging = true
-					ignoreNodes = append(ignoreNodes, levelNodeName)
+					ignoreNodes = append(ignoreNodes, []string{levelNodeName})
 				}
 			}
 		}
 	}
 
-	messageNodeName, messageNode := findWithAnyName(root, "message", "msg")
+	messageNodePath, messageNode := findWithAnyPath(root, r.jsonKeys.Message...)
 	if messageNode != nil {
 		if messageStr, err := messageNode.String(); err == nil {
 			message = messageStr
-			ignoreNodes = append(ignoreNodes, messageNodeName)
+			ignoreNodes = append(ignoreNodes, messageNodePath)
 
 			if isMongoDBLogging {
 				_, componentNode := findWithAnyName(root, "c")
@@ -87,10 +162,23 @@ func (r *Relogger) processLineJson(b []byte) bool {
 		}
 	}
 
-	timestampNodeName, timestampNode := findWithAnyName(root, "time", "timestamp", "@timestamp", "ts", "datetime")
+	var properties [][2]int
+	clef, isCLEF := parseCLEF(root)
+	if isCLEF {
+		ignoreNodes = append(ignoreNodes, clef.ignore...)
+		if messageNode == nil {
+			message = clef.message
+			properties = clef.properties
+		}
+		if level == zerolog.NoLevel {
+			level = zerolog.InfoLevel
+		}
+	}
+
+	timestampNodePath, timestampNode := findWithAnyPath(root, r.jsonKeys.Time...)
 	if t, ok := parseTimestampNode(timestampNode); ok {
 		parsedTime = t
-		ignoreNodes = append(ignoreNodes, timestampNodeName)
+		ignoreNodes = append(ignoreNodes, timestampNodePath)
 	} else if isMongoDBLogging {
 		timestampNode = root.GetByPath("t", "$date")
 		if t, ok := parseTimestampNode(timestampNode); ok {
@@ -105,9 +193,26 @@ func (r *Relogger) processLineJson(b []byte) bool {
 		}
 	}
 
-	stacktraceNodeName, stacktraceNode := findWithAnyName(root, "stacktrace", "stack_trace", "stack")
-	if stacktraceNode != nil {
-		ignoreNodes = append(ignoreNodes, stacktraceNodeName)
+	ecs, isECS := parseECS(root)
+	if isECS {
+		ignoreNodes = append(ignoreNodes, ecs.ignore...)
+		caller = ecs.caller
+		if ecs.stacktrace != "" {
+			message = message + stacktraceHeader + ecs.stacktrace
+		}
+	}
+
+	gcp, isGCP := parseGCP(root, isGCPLogEntry)
+	if isGCP {
+		ignoreNodes = append(ignoreNodes, gcp.ignore...)
+		if gcp.caller != "" {
+			caller = gcp.caller
+		}
+	}
+
+	stacktraceNodePath, stacktraceNode := findWithAnyPath(root, r.jsonKeys.Stacktrace...)
+	if stacktraceNode != nil && ecs.stacktrace == "" {
+		ignoreNodes = append(ignoreNodes, stacktraceNodePath)
 		if stacktraceNode.Type() == ast.V_ARRAY {
 			children, _ := stacktraceNode.ArrayUseNode()
 			var sb strings.Builder
@@ -116,37 +221,77 @@ func (r *Relogger) processLineJson(b []byte) bool {
 				sb.WriteString(childStr)
 				sb.WriteString("\n\t")
 			}
-			message = fmt.Sprintf("%s\n\tSTACKTRACE\n\t==========\n\t%s", message, sb.String())
+			message = message + stacktraceHeader + sb.String()
 		} else {
 			stacktraceStr, _ := stacktraceNode.String()
 			stacktraceStr = strings.ReplaceAll(stacktraceStr, "\n", "\n\t")
-			message = fmt.Sprintf("%s\n\tSTACKTRACE\n\t==========\n\t%s", message, stacktraceStr)
+			message = message + stacktraceHeader + stacktraceStr
 		}
 	}
 
 	//level_value=20000 logger_name=akka.kafka.internal.CommittableSubSourceStageLogic sourceActorSystem=Main sourceThread=Main-akka.actor.default-dispatcher-6 thread_name=Main-akka.actor.default-dispatcher-5
-	callerNodes := findManyWithAllNames(root, "caller_file_name", "caller_line_number", "caller_class_name", "caller_method_name")
-	if callerNodes != nil {
-		callerFileName, _ := callerNodes[0].String()
-		callerLineNumber, _ := callerNodes[1].String()
-		callerClassName, _ := callerNodes[2].String()
-		callerMethodName, _ := callerNodes[3].String()
-		caller = fmt.Sprintf("%s:%s (%s:%s)", callerFileName, callerLineNumber, callerClassName, callerMethodName)
-		ignoreNodes = append(ignoreNodes, "caller_file_name", "caller_line_number", "caller_class_name", "caller_method_name")
+	ls, isLogstash := parseLogstash(root, ignoreNodes)
+	if isLogstash {
+		ignoreNodes = append(ignoreNodes, ls.ignore...)
+		if caller == "" {
+			caller = ls.caller
+		}
+	} else if logback := logbackCaller(root); logback != "" {
+		caller = logback
+		ignoreNodes = append(ignoreNodes,
+			[]string{"caller_file_name"}, []string{"caller_line_number"},
+			[]string{"caller_class_name"}, []string{"caller_method_name"})
+	}
+
+	if caller == "" {
+		if source, ok := slogSourceCaller(root); ok {
+			caller = source
+			ignoreNodes = append(ignoreNodes, []string{"source"})
+		}
 	}
 
-	ev := log.WithLevel(level)
-	if caller != "" {
-		ev = ev.Str("caller", caller)
+	if caller == "" {
+		callerNodePath, callerNode := findWithAnyPath(root, r.jsonKeys.Caller...)
+		if callerNode != nil {
+			if callerStr, err := callerNode.String(); err == nil {
+				caller = callerStr
+				ignoreNodes = append(ignoreNodes, callerNodePath)
+			}
+		}
 	}
 
+	ev := r.newEntry(level)
+	ev.Caller = caller
+	ev.Properties = properties
+	ev.Fields = append(ev.Fields, ecs.fields...)
+	ev.Fields = append(ev.Fields, gcp.fields...)
+	ev.Fields = append(ev.Fields, ls.fields...)
+	ev.Fields = append(ev.Fields, clef.fields...)
+
 	root.ForEach(func(path ast.Sequence, node *ast.Node) bool {
 		if path.Key == nil {
 			return true
 		}
 		key := *path.Key
-		if slices.Contains(ignoreNodes, key) {
-			return true // skip, already processed
+		var nestedIgnores [][]string
+		for _, ignore := range ignoreNodes {
+			if ignore[0] != key {
+				continue
+			}
+			if len(ignore) == 1 {
+				return true // skip, already processed
+			}
+			nestedIgnores = append(nestedIgnores, ignore[1:])
+		}
+		if nestedIgnores != nil && node.Type() == ast.V_OBJECT {
+			obj := nodeValue(node).(Object)
+			for _, ignore := range nestedIgnores {
+				obj = removePath(obj, ignore)
+			}
+			if len(obj) > 0 {
+				ev = ev.Interface(key, obj)
+			}
+			return true
 		}
 		switch node.Type() {
 		case ast.V_NULL:
@@ -155,27 +300,114 @@ func (r *Relogger) processLineJson(b []byte) bool {
 			ev = ev.Bool(key, true)
 		case ast.V_FALSE:
 			ev = ev.Bool(key, false)
-		case ast.V_ARRAY:
-			arr, _ := node.Array()
-			ev = ev.Interface(key, arr)
-		case ast.V_OBJECT:
-			m, _ := node.Map()
-			ev = ev.Interface(key, m)
+		case ast.V_ARRAY, ast.V_OBJECT:
+			ev = ev.Interface(key, nodeValue(node))
 		case ast.V_STRING:
 			str, _ := node.String()
 			ev = ev.Str(key, str)
 		case ast.V_NUMBER:
-			num, _ := node.Number()
-			if i, err := strconv.ParseInt(num.String(), 10, 64); err == nil {
-				ev = ev.Int64(key, i)
-			} else if f, err := strconv.ParseFloat(num.String(), 64); err == nil {
-				ev = ev.Float64(key, f)
-			} else {
-				ev = ev.Str(key, num.String())
-			}
+			ev = ev.Interface(key, nodeValue(node))
 		}
 		return true
 	})
 	ev.Msg(message)
-	return true
+}
+
+// findWithAnyPath returns the node of the first of the dotted paths that
+// exists, together with the keys leading to it.
+func findWithAnyPath(node ast.Node, paths ...string) ([]string, *ast.Node) {
+	for _, path := range paths {
+		if keys, child := findPath(&node, path); child != nil {
+			return keys, child
+		}
+	}
+	return nil, nil
+}
+
+// findPath looks up a dotted path, where each dot may either separate the
+// keys of nested objects or be part of a key.
+func findPath(node *ast.Node, path string) ([]string, *ast.Node) {
+	if node.Type() != ast.V_OBJECT {
+		return nil, nil
+	}
+	if child := node.Get(path); child.Exists() {
+		return []string{path}, child
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		child := node.Get(path[:i])
+		if !child.Exists() {
+			continue
+		}
+		if keys, descendant := findPath(child, path[i+1:]); descendant != nil {
+			return append([]string{path[:i]}, keys...), descendant
+		}
+	}
+	return nil, nil
+}
+
+// removePath returns a copy of the object without the value at the path.
+// Objects left empty are removed as well.
+func removePath(obj Object, path []string) Object {
+	result := make(Object, 0, len(obj))
+	for _, field := range obj {
+		if field.Key != path[0] {
+			result = append(result, field)
+			continue
+		}
+		if len(path) == 1 {
+			continue
+		}
+		if child, ok := field.Value.(Object); ok {
+			child = removePath(child, path[1:])
+			if len(child) == 0 {
+				continue
+			}
+			field.Value = child
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+// nodeValue converts a JSON node to a field value, where objects keep the
+// order of their keys by being converted to an [Object].
+func nodeValue(node *ast.Node) any {
+	switch node.Type() {
+	case ast.V_TRUE:
+		return true
+	case ast.V_FALSE:
+		return false
+	case ast.V_STRING:
+		str, _ := node.String()
+		return str
+	case ast.V_NUMBER:
+		num, _ := node.Number()
+		if i, err := strconv.ParseInt(num.String(), 10, 64); err == nil {
+			return i
+		} else if f, err := strconv.ParseFloat(num.String(), 64); err == nil {
+			return f
+		}
+		return num.String()
+	case ast.V_ARRAY:
+		children, _ := node.ArrayUseNode()
+		arr := make([]any, len(children))
+		for i := range children {
+			arr[i] = nodeValue(&children[i])
+		}
+		return arr
+	case ast.V_OBJECT:
+		var obj Object
+		node.ForEach(func(path ast.Sequence, child *ast.Node) bool {
+			if path.Key != nil {
+				obj = append(obj, Field{Key: *path.Key, Value: nodeValue(child)})
+			}
+			return true
+		})
+		return obj
+	default:
+		return nil
+	}
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// relogString runs the input through a relogger and returns the entries.
+func relogString(t *testing.T, input string) []*Entry {
+	t.Helper()
+	rec := &entryRecorder{}
+	r := NewRelogger(strings.NewReader(input), rec, themes["dark"])
+	if err := r.RelogAll(); err != nil {
+		t.Fatal(err)
+	}
+	return rec.entries
+}
+
+func assertFieldsJSON(t *testing.T, want string, e *Entry) {
+	t.Helper()
+	b, err := json.Marshal(Object(e.Fields))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqualString(t, want, string(b), "fields")
+}
+
+func TestProcessJSONNestedPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields string
+	}{
+		{
+			name:   "nested",
+			input:  `{"@timestamp":"2022-09-20T17:56:28Z","log":{"level":"warn","logger":"com.example.Foo","origin":"x"},"message":"hello","error":{"type":"IOException","stack_trace":"at Foo"}}`,
+			fields: `{"log":{"origin":"x"},"error":{"type":"IOException"}}`,
+		},
+		{
+			name:   "flattened",
+			input:  `{"@timestamp":"2022-09-20T17:56:28Z","log.level":"warn","log.logger":"com.example.Foo","message":"hello","error.stack_trace":"at Foo"}`,
+			fields: `{}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := relogString(t, tc.input+"\n")
+			if len(entries) != 1 {
+				t.Fatalf("want 1 entry, got %d", len(entries))
+			}
+			e := entries[0]
+			if e.Level != zerolog.WarnLevel {
+				t.Errorf("want level warn, got %s", e.Level)
+			}
+			assertEqualString(t, "com.example.Foo", e.Caller, "caller")
+			assertEqualString(t, "hello"+stacktraceHeader+"at Foo", e.Message, "message")
+			assertFieldsJSON(t, tc.fields, e)
+		})
+	}
+}
+
+func TestProcessJSONECS(t *testing.T) {
+	input := `{"@timestamp":"2022-09-20T17:56:28.918Z","log.level":"ERROR","message":"Request failed","ecs.version":"1.2.0","service.name":"orders","event.dataset":"orders.log","process.thread.name":"main","log.logger":"com.example.OrderService","trace.id":"abc","transaction.id":"def","error.type":"java.io.IOException","error.message":"Broken pipe","error.stack_trace":"java.io.IOException: Broken pipe\n\tat com.example.OrderService.send(OrderService.java:42)"}` + "\n"
+	entries := relogString(t, input)
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zerolog.ErrorLevel {
+		t.Errorf("want level error, got %s", e.Level)
+	}
+	assertEqualString(t, "com.example.OrderService", e.Caller, "caller")
+	assertEqualString(t, "Request failed"+stacktraceHeader+"java.io.IOException: Broken pipe\n\t\tat com.example.OrderService.send(OrderService.java:42)", e.Message, "message")
+	assertFieldsJSON(t, `{"source":"orders","trace.id":"abc","transaction.id":"def","event.dataset":"orders.log","process.thread.name":"main"}`, e)
+}
+
+func TestProcessJSONGCP(t *testing.T) {
+	structured := `{"severity":"NOTICE","message":"Request served","time":"2022-09-20T17:56:28Z","logging.googleapis.com/sourceLocation":{"file":"main.go","line":"42","function":"main.handle"},"logging.googleapis.com/trace":"projects/my-project/traces/0123abc","httpRequest":{"requestMethod":"GET","requestUrl":"https://example.com/","status":200,"latency":"0.012s"},"user":"bob"}`
+	gcloud := `[
+  {
+    "insertId": "abc",
+    "jsonPayload": {"message": "Request served", "user": "bob"},
+    "logName": "projects/my-project/logs/stdout",
+    "receiveTimestamp": "2022-09-20T17:56:29Z",
+    "severity": "CRITICAL",
+    "sourceLocation": {"file": "main.go", "line": "42", "function": "main.handle"},
+    "timestamp": "2022-09-20T17:56:28Z"
+  }
+]`
+	entries := relogString(t, structured+"\n"+gcloud+"\n")
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zerolog.InfoLevel {
+		t.Errorf("want level info, got %s", e.Level)
+	}
+	assertEqualString(t, "main.go:42 (main.handle)", e.Caller, "caller")
+	assertEqualString(t, "Request served", e.Message, "message")
+	assertFieldsJSON(t, `{"trace":"0123abc","httpRequest":"GET https://example.com/ 200 0.012s","user":"bob"}`, e)
+
+	e = entries[1]
+	if e.Level != zerolog.FatalLevel {
+		t.Errorf("want level fatal, got %s", e.Level)
+	}
+	assertEqualString(t, "main.go:42 (main.handle)", e.Caller, "caller")
+	assertEqualString(t, "Request served", e.Message, "message")
+	assertFieldsJSON(t, `{"user":"bob"}`, e)
+	if e.Time.IsZero() {
+		t.Error("want time to be parsed")
+	}
+}
+
+func TestProcessJSONArrayRaw(t *testing.T) {
+	input := `[
+  {"severity": "INFO", "jsonPayload": {"message": "first"}, "timestamp": "2022-09-20T17:56:28Z"},
+  {"severity": "WARNING", "jsonPayload": {"message": "second"}, "timestamp": "2022-09-20T17:56:29Z"}
+]`
+	entries := relogString(t, input+"\n")
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		assertEqualString(t, input, strings.Join(e.Raw, "\n"), e.Message+" raw lines")
+	}
+}
+
+func TestProcessJSONNotGCP(t *testing.T) {
+	input := `{"level":"info","message":"served","caller":"server.go:12","sourceLocation":{"file":"main.go","line":"42"},"trace":"projects/p/traces/abc","httpRequest":{"requestMethod":"GET"}}` + "\n"
+	entries := relogString(t, input)
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	assertEqualString(t, "server.go:12", entries[0].Caller, "caller")
+	assertFieldsJSON(t, `{"sourceLocation":{"file":"main.go","line":"42"},"trace":"projects/p/traces/abc","httpRequest":{"requestMethod":"GET"}}`, entries[0])
+}
+
+func TestProcessJSONLogstash(t *testing.T) {
+	input := `{"@timestamp":"2022-09-20T17:56:28.918+02:00","@version":"1","message":"Order sent","logger_name":"com.example.orders.service.internal.OrderService","thread_name":"http-nio-8080-exec-1","level":"WARN","level_value":30000,"request_id":"r1","tags":["billing"],"stack_trace":"java.io.IOException: Broken pipe\n\tat Foo"}` + "\n"
+	entries := relogString(t, input)
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zerolog.WarnLevel {
+		t.Errorf("want level warn, got %s", e.Level)
+	}
+	assertEqualString(t, "c.e.o.service.internal.OrderService", e.Caller, "caller")
+	assertEqualString(t, "Order sent"+stacktraceHeader+"java.io.IOException: Broken pipe\n\t\tat Foo", e.Message, "message")
+	assertFieldsJSON(t, `{"thread_name":"http-nio-8080-exec-1","tags":["billing"],"mdc":{"request_id":"r1"}}`, e)
+}
+
+func TestProcessJSONLogstashECS(t *testing.T) {
+	input := `{"@timestamp":"2022-09-20T17:56:28.918Z","@version":"1","ecs.version":"1.2.0","message":"Order sent","logger_name":"com.example.OrderService","log.logger":"OrderService","service.name":"orders","level":"INFO","request_id":"r1"}` + "\n"
+	e := relogString(t, input)[0]
+	assertEqualString(t, "OrderService", e.Caller, "caller")
+	assertFieldsJSON(t, `{"source":"orders","mdc":{"request_id":"r1"}}`, e)
+}
+
+func TestLogbackCallerPartial(t *testing.T) {
+	entries := relogString(t, `{"level":"INFO","message":"hi","caller_file_name":"Foo.java","caller_line_number":12}`+"\n")
+	assertEqualString(t, "Foo.java:12", entries[0].Caller, "caller")
+	assertFieldsJSON(t, `{}`, entries[0])
+}
+
+func TestProcessJSONCLEF(t *testing.T) {
+	input := `{"@t":"2022-09-20T17:56:28.918Z","@mt":"User {UserId} logged in from {@Address} in {Elapsed:0.00} ms {{ok}}","UserId":"bob","Address":{"City":"Oslo"},"Elapsed":12.5,"SourceContext":"App.Auth","@i":"a1b2"}
+{"@t":"2022-09-20T17:56:29Z","@m":"Failed","@l":"Error","@x":"System.Exception: Boom\n   at App.Main()"}
+`
+	entries := relogString(t, input)
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zerolog.InfoLevel {
+		t.Errorf("want level info, got %s", e.Level)
+	}
+	assertEqualString(t, "App.Auth", e.Caller, "caller")
+	assertEqualString(t, `User "bob" logged in from {"City":"Oslo"} in 12.5 ms {ok}`, e.Message, "message")
+	wantProperties := [][2]int{{5, 10}, {26, 41}, {45, 49}}
+	if fmt.Sprint(e.Properties) != fmt.Sprint(wantProperties) {
+		t.Errorf("want properties %v, got %v", wantProperties, e.Properties)
+	}
+	assertFieldsJSON(t, `{"event_id":"a1b2"}`, e)
+
+	e = entries[1]
+	if e.Level != zerolog.ErrorLevel {
+		t.Errorf("want level error, got %s", e.Level)
+	}
+	assertEqualString(t, "Failed"+stacktraceHeader+"System.Exception: Boom\n\t   at App.Main()", e.Message, "message")
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/go-logfmt/logfmt"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
 var klogLogRegex = regexp.MustCompile(`^([EWIDT])(\d{4} \d{2}:\d{2}:\d{2}(?:\.\d+)?) +\d +([^\]]+)\] +(?:"([^"]*)")? *((?:\S+=.*)*)(.*)$`)
@@ -33,8 +32,8 @@ func (r *Relogger) processLineKlog(b []byte) bool {
 	parsedTime = timeParsed
 
 	level := parseKlogLevel(levelGroup)
-	ev := log.WithLevel(level)
-	ev = ev.Str("caller", string(callerGroup))
+	ev := r.newEntry(level)
+	ev.Caller = string(callerGroup)
 
 	if len(logfmtGroup) > 0 {
 		dec := logfmt.NewDecoder(bytes.NewReader(logfmtGroup))
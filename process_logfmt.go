@@ -5,16 +5,16 @@ import (
 	"errors"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logfmt/logfmt"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
 var crudeLogfmtRegex = regexp.MustCompile(`^\w+=[^ ]+`)
 
-func (r Relogger) processLineLogFmt(b []byte) bool {
+func (r *Relogger) processLineLogFmt(b []byte) bool {
 	if !crudeLogfmtRegex.Match(b) {
 		return false
 	}
@@ -32,16 +32,18 @@ func (r Relogger) processLineLogFmt(b []byte) bool {
 		hasCaller    bool
 	)
 	var fields []Pair
+	var keys []string
 	for d.ScanKeyval() {
 		pair := Pair{string(d.Key()), string(d.Value())}
+		keys = append(keys, pair.Key)
 		if !hasTimestamp && (pair.Key == "time" || pair.Key == "timestamp" || pair.Key == "@timestamp" || pair.Key == "ts" || pair.Key == "t" || pair.Key == "datetime") {
 			if t, ok := ParseFuzzyTime(pair.Value); ok {
 				timestamp = t
 				hasTimestamp = true
 				continue
 			}
-		} else if !hasLevel && (pair.Key == "level" || pair.Key == "lvl" || pair.Key == "severity") {
-			level = parseLevel(pair.Value)
+		} else if !hasLevel && (pair.Key == "level" || pair.Key == "lvl" || pair.Key == "severity" || pair.Key == "level_value") {
+			level = parseLevelWithScale(pair.Value, levelScaleForKey(pair.Key, r.levelScale))
 			hasLevel = true
 			continue
 		} else if !hasMessage && (pair.Key == "message" || pair.Key == "msg") {
@@ -56,9 +58,25 @@ func (r Relogger) processLineLogFmt(b []byte) bool {
 	if hasTimestamp {
 		parsedTime = timestamp
 	}
-	ev := log.WithLevel(level)
+	ev := r.newEntry(level)
+	// slog's text handler writes groups as dotted keys, which are nested
+	// the same way as the objects of its JSON handler.
+	groupDotted := isSlogTextRecord(keys)
+	groups := map[string]int{}
 	for _, pair := range fields {
-		ev = addLogfmtEventField(ev, pair, hasCaller)
+		group, rest, isDotted := strings.Cut(pair.Key, ".")
+		if !groupDotted || !isDotted {
+			ev = addLogfmtEventField(ev, pair, hasCaller)
+			continue
+		}
+		i, ok := groups[group]
+		if !ok {
+			i = len(ev.Fields)
+			groups[group] = i
+			ev = ev.Interface(group, Object(nil))
+		}
+		obj, _ := ev.Fields[i].Value.(Object)
+		ev.Fields[i].Value = setDotted(obj, strings.Split(rest, "."), logfmtValue(pair.Value))
 	}
 	if len(fields) == 0 && !hasMessage && !hasLevel && !hasTimestamp {
 		return false
@@ -72,8 +90,25 @@ type Pair struct {
 	Value string
 }
 
-func addLogfmtEventField(ev *zerolog.Event, pair Pair, hasCaller bool) *zerolog.Event {
-	if i, err := strconv.ParseInt(pair.Value, 10, 64); err == nil {
+// logfmtValue converts the value to a number or bool, if it is one.
+func logfmtValue(value string) any {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	} else if value == "true" {
+		return true
+	} else if value == "false" {
+		return false
+	}
+	return value
+}
+
+func addLogfmtEventField(ev *Entry, pair Pair, hasCaller bool) *Entry {
+	if pair.Key == "caller" {
+		ev.Caller = pair.Value
+		return ev
+	} else if i, err := strconv.ParseInt(pair.Value, 10, 64); err == nil {
 		return ev.Int64(pair.Key, i)
 	} else if f, err := strconv.ParseFloat(pair.Value, 64); err == nil {
 		return ev.Float64(pair.Key, f)
@@ -84,7 +119,8 @@ func addLogfmtEventField(ev *zerolog.Event, pair Pair, hasCaller bool) *zerolog.
 	} else if pair.Key == "err" {
 		return ev.Err(errors.New(pair.Value))
 	} else if (pair.Key == "logger" || pair.Key == "source") && !hasCaller {
-		return ev.Str("caller", pair.Value)
+		ev.Caller = pair.Value
+		return ev
 	} else {
 		return ev.Str(pair.Key, pair.Value)
 	}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic/ast"
+)
+
+// logstashEntry holds the parts of a logstash-logback-encoder log line that
+// are rendered differently than other JSON logs.
+// https://github.com/logfellow/logstash-logback-encoder#standard-fields
+type logstashEntry struct {
+	caller string
+	// fields are printed before the other fields, with the MDC fields
+	// grouped under "mdc".
+	fields []Field
+	// ignore holds the paths of the processed nodes.
+	ignore [][]string
+}
+
+// logstashStandardKeys are the fields written by the encoder itself, as
+// opposed to the MDC and structured argument fields.
+var logstashStandardKeys = []string{
+	"@timestamp", "@version", "message", "logger_name", "thread_name",
+	"level", "level_value", "stack_trace", "stack_hash", "tags",
+	"caller_file_name", "caller_line_number", "caller_class_name", "caller_method_name",
+}
+
+// parseLogstash returns the logstash-logback-encoder specific parts of the
+// line, if it is in that format, as identified by the "@version" or
+// "level_value" fields together with "logger_name". The mapped paths are
+// the nodes already processed by other formats, which are left out of "mdc".
+func parseLogstash(root ast.Node, mapped [][]string) (logstashEntry, bool) {
+	var ls logstashEntry
+	if !root.Get("logger_name").Exists() ||
+		(!root.Get("@version").Exists() && !root.Get("level_value").Exists()) {
+		return ls, false
+	}
+
+	ls.caller = logbackCaller(root)
+	if ls.caller == "" {
+		if logger, err := root.Get("logger_name").String(); err == nil {
+			ls.caller = abbreviateLogger(logger, 36)
+		}
+	}
+	if thread, err := root.Get("thread_name").String(); err == nil {
+		ls.fields = append(ls.fields, Field{Key: "thread_name", Value: thread})
+	}
+	if tags := root.Get("tags"); tags.Exists() {
+		ls.fields = append(ls.fields, Field{Key: "tags", Value: nodeValue(tags)})
+	}
+
+	var mdc Object
+	root.ForEach(func(path ast.Sequence, node *ast.Node) bool {
+		if path.Key == nil {
+			return true
+		}
+		for _, key := range logstashStandardKeys {
+			if *path.Key == key {
+				ls.ignore = append(ls.ignore, []string{key})
+				return true
+			}
+		}
+		for _, p := range mapped {
+			if len(p) > 0 && p[0] == *path.Key {
+				return true
+			}
+		}
+		mdc = append(mdc, Field{Key: *path.Key, Value: nodeValue(node)})
+		ls.ignore = append(ls.ignore, []string{*path.Key})
+		return true
+	})
+	if len(mdc) > 0 {
+		ls.fields = append(ls.fields, Field{Key: "mdc", Value: mdc})
+	}
+	return ls, true
+}
+
+// logbackCaller returns the caller from logback's caller data fields, such
+// as "OrderService.java:42 (com.example.OrderService:send)", using the ones
+// that are set.
+func logbackCaller(root ast.Node) string {
+	str := func(key string) string {
+		s, _ := root.Get(key).String()
+		return s
+	}
+	file, line := str("caller_file_name"), str("caller_line_number")
+	class, method := str("caller_class_name"), str("caller_method_name")
+	var caller string
+	switch {
+	case file != "" && line != "":
+		caller = file + ":" + line
+	case file != "":
+		caller = file
+	}
+	switch {
+	case class != "" && method != "":
+		caller = strings.TrimSpace(fmt.Sprintf("%s (%s:%s)", caller, class, method))
+	case class != "":
+		caller = strings.TrimSpace(fmt.Sprintf("%s (%s)", caller, class))
+	}
+	return caller
+}
+
+// abbreviateLogger shortens the package names of a logger name to their
+// first letter, from the left, until it fits within maxLen, the same way as
+// logback's %logger{maxLen} does. The class name is never shortened.
+func abbreviateLogger(name string, maxLen int) string {
+	if len(name) <= maxLen {
+		return name
+	}
+	parts := strings.Split(name, ".")
+	length := len(name)
+	for i := 0; i < len(parts)-1 && length > maxLen; i++ {
+		if len(parts[i]) > 1 {
+			length -= len(parts[i]) - 1
+			parts[i] = parts[i][:1]
+		}
+	}
+	return strings.Join(parts, ".")
+}
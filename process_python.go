@@ -0,0 +1,119 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Python's logging module with the default format, such as
+// "WARNING:app.db:Slow query".
+var pythonLoggingRegex = regexp.MustCompile(`^(DEBUG|INFO|WARNING|ERROR|CRITICAL):([^\s:]+):(.*)$`)
+
+// uvicorn's default logging, such as
+// `INFO:     127.0.0.1:1234 - "GET / HTTP/1.1" 200` or
+// "INFO:     Started server process [1]", where the level is padded so the
+// messages start at column 10.
+var (
+	uvicornRegex       = regexp.MustCompile(`^((DEBUG|INFO|WARNING|ERROR|CRITICAL): +)(\S.*)$`)
+	uvicornAccessRegex = regexp.MustCompile(`^(\S+) - "(\S+) (\S+) (HTTP/[\d.]+)" (\d{3})\b`)
+)
+
+// structlog's ConsoleRenderer, such as
+// "2023-05-01 12:00:00 [info     ] User logged in     [app.auth] user=bob",
+// where the level is padded to the length of "exception".
+var (
+	structlogRegex       = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?) \[((\w+) *)\] (.*)$`)
+	structlogLoggerRegex = regexp.MustCompile(`^(.*?)(?:\s+\[([\w.\-]+)\])?\s*$`)
+	pythonKeyValueRegex  = regexp.MustCompile(`^([\w.\-]+)=('[^']*'|"[^"]*"|[^\s'"]*)(?:\s|$)`)
+)
+
+const structlogLevelWidth = len("exception")
+
+func (r *Relogger) processLinePython(b []byte) bool {
+	s := stripANSI(string(b))
+	if groups := pythonLoggingRegex.FindStringSubmatch(s); groups != nil {
+		ev := r.newEntry(parseLevel(groups[1]))
+		ev.Caller = groups[2]
+		ev.Msg(groups[3])
+		return true
+	}
+	if groups := uvicornRegex.FindStringSubmatch(s); groups != nil && len(groups[1]) == 10 {
+		ev := r.newEntry(parseLevel(groups[2]))
+		if access := uvicornAccessRegex.FindStringSubmatch(groups[3]); access != nil {
+			ev.Caller = "uvicorn.access"
+			ev = ev.Str("client", access[1]).
+				Str("protocol", access[4]).
+				Interface("status", logfmtValue(access[5]))
+			ev.Msg(access[2] + " " + access[3])
+			return true
+		}
+		ev.Caller = "uvicorn"
+		ev.Msg(groups[3])
+		return true
+	}
+	if groups := structlogRegex.FindStringSubmatch(s); groups != nil && len(groups[2]) == structlogLevelWidth {
+		t, ok := parsePythonTime(groups[1])
+		if !ok {
+			return false
+		}
+		parsedTime = t
+		text, pairs := splitPythonKeyValues(groups[4])
+		rest := structlogLoggerRegex.FindStringSubmatch(text)
+		ev := r.newEntry(parseLevel(groups[3]))
+		ev.Caller = rest[2]
+		for _, pair := range pairs {
+			ev = addLogfmtEventField(ev, pair, true)
+		}
+		ev.Msg(strings.TrimSpace(rest[1]))
+		return true
+	}
+	return false
+}
+
+func parsePythonTime(s string) (time.Time, bool) {
+	s = strings.Replace(s, ",", ".", 1)
+	if t, ok := ParseFuzzyTime(s); ok {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05.999999999Z0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitPythonKeyValues splits the trailing key=value pairs from the text
+// before them, where the values may be quoted with single or double quotes,
+// as written by Python's repr(). Pairs followed by other text are kept in
+// the text.
+func splitPythonKeyValues(s string) (string, []Pair) {
+	textEnd := len(s)
+	var pairs []Pair
+	for i := 0; i < len(s); {
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+		groups := pythonKeyValueRegex.FindStringSubmatch(s[i:])
+		if groups == nil {
+			// Not a pair, so the pairs so far were part of the text.
+			end := strings.IndexByte(s[i:], ' ')
+			if end < 0 {
+				end = len(s) - i
+			}
+			i += end
+			textEnd = i
+			pairs = pairs[:0]
+			continue
+		}
+		value := groups[2]
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') {
+			value = value[1 : len(value)-1]
+		}
+		pairs = append(pairs, Pair{Key: groups[1], Value: value})
+		i += len(groups[0])
+	}
+	return s[:textEnd], pairs
+}
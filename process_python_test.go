@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestProcessPython(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		level   zerolog.Level
+		caller  string
+		message string
+		fields  string
+	}{
+		{
+			name:    "logging",
+			input:   "WARNING:app.db:Slow query: SELECT 1",
+			level:   zerolog.WarnLevel,
+			caller:  "app.db",
+			message: "Slow query: SELECT 1",
+			fields:  `{}`,
+		},
+		{
+			name:    "uvicorn access",
+			input:   `INFO:     127.0.0.1:52130 - "GET /health HTTP/1.1" 200 OK`,
+			level:   zerolog.InfoLevel,
+			caller:  "uvicorn.access",
+			message: "GET /health",
+			fields:  `{"client":"127.0.0.1:52130","protocol":"HTTP/1.1","status":200}`,
+		},
+		{
+			name:    "uvicorn",
+			input:   "\x1b[32mINFO\x1b[0m:     Started server process [1]",
+			level:   zerolog.InfoLevel,
+			caller:  "uvicorn",
+			message: "Started server process [1]",
+			fields:  `{}`,
+		},
+		{
+			name:    "structlog",
+			input:   "2023-05-01 12:00:00 [warning  ] User logged in                 [app.auth] user=bob attempts=3 note='two words'",
+			level:   zerolog.WarnLevel,
+			caller:  "app.auth",
+			message: "User logged in",
+			fields:  `{"user":"bob","attempts":3,"note":"two words"}`,
+		},
+		{
+			name:    "structlog text after pair",
+			input:   "2023-05-01 12:00:00 [info     ] retry x=1 because of timeout   attempt=2",
+			level:   zerolog.InfoLevel,
+			message: "retry x=1 because of timeout",
+			fields:  `{"attempt":2}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := relogString(t, tc.input+"\n")
+			if len(entries) != 1 {
+				t.Fatalf("want 1 entry, got %d", len(entries))
+			}
+			e := entries[0]
+			if e.Level != tc.level {
+				t.Errorf("want level %s, got %s", tc.level, e.Level)
+			}
+			assertEqualString(t, tc.caller, e.Caller, "caller")
+			assertEqualString(t, tc.message, e.Message, "message")
+			assertFieldsJSON(t, tc.fields, e)
+		})
+	}
+}
+
+func TestProcessPythonNotUvicorn(t *testing.T) {
+	for _, input := range []string{
+		"ERROR: could not open requirements file",
+		"INFO: x",
+		"WARNING: deprecated option",
+	} {
+		entries := relogString(t, input+"\n")
+		if len(entries) != 1 {
+			t.Fatalf("%q: want 1 entry, got %d", input, len(entries))
+		}
+		if entries[0].Caller == "uvicorn" {
+			t.Errorf("%q: want no uvicorn caller", input)
+		}
+	}
+}
+
+func TestProcessPythonNotStructlog(t *testing.T) {
+	entries := relogString(t, "2023-05-01 12:00:00 [INFO] plain bracketed level\n")
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	if !entries[0].Unparsed {
+		t.Errorf("want the line left to the string processor, got %+v", entries[0])
+	}
+}
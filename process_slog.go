@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic/ast"
+	"github.com/rs/zerolog"
+)
+
+// slogLevelRegex matches the levels of Go's log/slog package that are
+// between the named ones, such as "DEBUG+2" or "ERROR-1".
+var slogLevelRegex = regexp.MustCompile(`^(?i)(DEBUG|INFO|WARN|ERROR)([+-]\d+)$`)
+
+// parseSlogLevel parses a level with an offset, by mapping it to the named
+// level it falls within.
+func parseSlogLevel(levelStr string) (zerolog.Level, bool) {
+	groups := slogLevelRegex.FindStringSubmatch(levelStr)
+	if groups == nil {
+		return zerolog.NoLevel, false
+	}
+	offset, err := strconv.Atoi(groups[2])
+	if err != nil {
+		return zerolog.NoLevel, false
+	}
+	var n int
+	switch strings.ToUpper(groups[1]) {
+	case "DEBUG":
+		n = -4
+	case "INFO":
+		n = 0
+	case "WARN":
+		n = 4
+	case "ERROR":
+		n = 8
+	}
+	n += offset
+	switch {
+	case n < -4:
+		return zerolog.TraceLevel, true
+	case n < 0:
+		return zerolog.DebugLevel, true
+	case n < 4:
+		return zerolog.InfoLevel, true
+	case n < 8:
+		return zerolog.WarnLevel, true
+	default:
+		return zerolog.ErrorLevel, true
+	}
+}
+
+// slogSourceCaller returns the caller from the "source" object written by
+// slog's JSON handler, such as {"function":"main.main","file":"/app/main.go","line":12}.
+func slogSourceCaller(root ast.Node) (string, bool) {
+	source := root.Get("source")
+	if !source.Exists() || source.Type() != ast.V_OBJECT {
+		return "", false
+	}
+	file, err := source.Get("file").String()
+	if err != nil || file == "" {
+		return "", false
+	}
+	if line, err := source.Get("line").String(); err == nil && line != "" {
+		return file + ":" + line, true
+	}
+	return file, true
+}
+
+// isSlogTextRecord reports if the keys are from slog's text handler, which
+// always starts with the time and level.
+func isSlogTextRecord(keys []string) bool {
+	return len(keys) >= 3 && keys[0] == "time" && keys[1] == "level"
+}
+
+// setDotted sets the value at the dotted path of keys, such as from slog's
+// groups, creating the nested objects along the way.
+func setDotted(obj Object, path []string, value any) Object {
+	for i, field := range obj {
+		if field.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			obj[i].Value = value
+			return obj
+		}
+		if child, ok := field.Value.(Object); ok {
+			obj[i].Value = setDotted(child, path[1:], value)
+			return obj
+		}
+	}
+	if len(path) == 1 {
+		return append(obj, Field{Key: path[0], Value: value})
+	}
+	return append(obj, Field{Key: path[0], Value: setDotted(nil, path[1:], value)})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestParseSlogLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  zerolog.Level
+	}{
+		{"DEBUG+2", zerolog.DebugLevel},
+		{"DEBUG-1", zerolog.TraceLevel},
+		{"INFO+4", zerolog.WarnLevel},
+		{"WARN-1", zerolog.InfoLevel},
+		{"ERROR+4", zerolog.ErrorLevel},
+	}
+	for _, tc := range tests {
+		if got := parseLevel(tc.input); got != tc.want {
+			t.Errorf("%q: want %s, got %s", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestProcessSlogHandlers(t *testing.T) {
+	input := `{"time":"2023-05-01T12:00:00Z","level":"DEBUG+2","source":{"function":"main.main","file":"/app/main.go","line":12},"msg":"request","req":{"method":"GET","path":"/"},"status":200}
+time=2023-05-01T12:00:00Z level=DEBUG+2 source=/app/main.go:12 msg=request req.method=GET req.path=/ status=200
+`
+	entries := relogString(t, input)
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Level != zerolog.DebugLevel {
+			t.Errorf("want level debug, got %s", e.Level)
+		}
+		assertEqualString(t, "/app/main.go:12", e.Caller, "caller")
+		assertEqualString(t, "request", e.Message, "message")
+		assertFieldsJSON(t, `{"req":{"method":"GET","path":"/"},"status":200}`, e)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/bytedance/sonic"
-	"github.com/rs/zerolog/log"
+	"github.com/bytedance/sonic/ast"
 )
 
 var kubernetesLogRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z)\t([A-Z]+)\t(?:([a-z0-9\.\-]+)\t)?([^\{]+)(?:\t(\{.*))?$`)
@@ -27,24 +27,26 @@ func (r *Relogger) processLineZap(b []byte) bool {
 		return false
 	}
 
-	var data map[string]any
+	var data Object
 	if len(jsonGroup) > 0 {
-		if err := sonic.Unmarshal(jsonGroup, &data); err != nil {
+		root, err := sonic.Get(jsonGroup)
+		if err != nil || root.Type() != ast.V_OBJECT {
 			return false
 		}
+		data, _ = nodeValue(&root).(Object)
 	}
 
 	parsedTime = timeParsed
 
 	level := parseLevel(string(levelGroup))
-	ev := log.WithLevel(level)
+	ev := r.newEntry(level)
 
 	if len(callerGroup) > 0 {
-		ev = ev.Str("caller", string(callerGroup))
+		ev.Caller = string(callerGroup)
 	}
 
-	for k, v := range data {
-		ev = ev.Interface(k, v)
+	for _, field := range data {
+		ev = ev.Interface(field.Key, field.Value)
 	}
 
 	ev.Msg(string(messageGroup))
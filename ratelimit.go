@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RateLimitWriter drops entries above a maximum rate, and writes a marker
+// with the number of suppressed entries before the next entry that passes,
+// once per second while entries keep being suppressed, or once the input goes
+// idle. Continuation lines are kept or dropped together with their entry.
+type RateLimitWriter struct {
+	Next EntryWriter
+	// Rate is the maximum number of entries per second, which is also the
+	// size of the allowed burst, of at least one entry.
+	Rate float64
+	// KeepErrors lets entries of error level and higher bypass the limit.
+	KeepErrors bool
+	// Idle is how long to wait for more entries before writing the marker
+	// of the suppressed ones. Zero waits until the next entry or Close.
+	Idle time.Duration
+
+	mu         sync.Mutex
+	timer      *time.Timer
+	now        func() time.Time
+	tokens     float64
+	last       time.Time
+	suppressed int
+	// suppressedSince is when the suppressed entries started being counted.
+	suppressedSince time.Time
+	lastTime        time.Time
+	keptLast        bool
+	seen            bool
+	// err is the error of writing the marker when the input went idle,
+	// which is returned by the next call instead.
+	err error
+}
+
+func (w *RateLimitWriter) WriteEntry(e *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if e.Continuation && w.seen {
+		if !w.keptLast {
+			return nil
+		}
+		return w.Next.WriteEntry(e)
+	}
+	w.seen = true
+	if !e.Time.IsZero() {
+		w.lastTime = e.Time
+	}
+	now := w.clock()
+	w.keptLast = w.allow(e, now)
+	if !w.keptLast {
+		if w.suppressed == 0 {
+			w.suppressedSince = now
+		}
+		w.suppressed++
+		if now.Sub(w.suppressedSince) >= suppressedMarkerInterval {
+			return w.writeSuppressed(e.Time)
+		}
+		w.resetTimer()
+		return nil
+	}
+	if err := w.writeSuppressed(e.Time); err != nil {
+		return err
+	}
+	return w.Next.WriteEntry(e)
+}
+
+func (w *RateLimitWriter) Close() error {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	err := w.err
+	if err == nil {
+		err = w.writeSuppressed(w.lastTime)
+	}
+	w.mu.Unlock()
+	if errors.Is(err, ErrStopReading) {
+		// Not a failure, only that the output is done with the entries.
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return w.Next.Close()
+}
+
+func (w *RateLimitWriter) resetTimer() {
+	if w.Idle <= 0 {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.Idle, w.onIdle)
+		return
+	}
+	w.timer.Reset(w.Idle)
+}
+
+// onIdle writes the marker when the flood has stopped, such as when
+// following the logs of a pod, instead of waiting for the next entry.
+func (w *RateLimitWriter) onIdle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.writeSuppressed(w.lastTime)
+	}
+}
+
+// suppressedMarkerInterval is how often the marker is written during a
+// flood, where no entries pass.
+const suppressedMarkerInterval = time.Second
+
+func (w *RateLimitWriter) clock() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+func (w *RateLimitWriter) allow(e *Entry, now time.Time) bool {
+	// Rates below one per second, such as 10/m, still allow one entry.
+	burst := w.Rate
+	if burst < 1 {
+		burst = 1
+	}
+	if w.last.IsZero() {
+		w.tokens = burst
+	} else {
+		w.tokens += now.Sub(w.last).Seconds() * w.Rate
+		if w.tokens > burst {
+			w.tokens = burst
+		}
+	}
+	w.last = now
+	if w.KeepErrors && e.Level >= zerolog.ErrorLevel && e.Level != zerolog.NoLevel {
+		return true
+	}
+	if w.tokens < 1 {
+		return false
+	}
+	w.tokens--
+	return true
+}
+
+// writeSuppressed writes the marker of the suppressed entries, if any. Must
+// be called with the mutex held.
+func (w *RateLimitWriter) writeSuppressed(t time.Time) error {
+	if w.suppressed == 0 {
+		return nil
+	}
+	marker := &Entry{
+		Level:   zerolog.NoLevel,
+		Time:    t,
+		Message: fmt.Sprintf("… %d entries suppressed by --max-rate", w.suppressed),
+	}
+	w.suppressed = 0
+	return w.Next.WriteEntry(marker)
+}
+
+// ParseRate parses a rate such as "50/s", "100/m", or "50", which is per
+// second, into the number of entries per second.
+func ParseRate(s string) (float64, error) {
+	count, unit, hasUnit := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q, must be a positive number such as 50/s", s)
+	}
+	if !hasUnit {
+		return n, nil
+	}
+	if unit != "" && (unit[0] < '0' || unit[0] > '9') {
+		unit = "1" + unit
+	}
+	per, err := time.ParseDuration(unit)
+	if err != nil || per <= 0 {
+		return 0, fmt.Errorf("invalid rate %q, must be per a unit such as s, m, or h", s)
+	}
+	return n / per.Seconds(), nil
+}
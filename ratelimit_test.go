@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRateLimitWriter(t *testing.T) {
+	rec := &entryRecorder{}
+	now := time.Date(2022, 9, 20, 17, 0, 0, 0, time.UTC)
+	w := &RateLimitWriter{Next: rec, Rate: 2, KeepErrors: true, now: func() time.Time { return now }}
+
+	write := func(level zerolog.Level, msg string) {
+		w.WriteEntry(&Entry{Level: level, Message: msg})
+	}
+	write(zerolog.InfoLevel, "a")
+	write(zerolog.InfoLevel, "b")
+	write(zerolog.InfoLevel, "c")
+	w.WriteEntry(&Entry{Message: "  c continuation", Continuation: true})
+	write(zerolog.ErrorLevel, "error")
+	write(zerolog.InfoLevel, "d")
+	now = now.Add(time.Second)
+	write(zerolog.InfoLevel, "e")
+	w.Close()
+
+	assertMessages(t, []string{
+		"a", "b",
+		"… 1 entries suppressed by --max-rate",
+		"error",
+		"… 1 entries suppressed by --max-rate",
+		"e",
+	}, rec.messages())
+}
+
+func TestRateLimitWriterFlood(t *testing.T) {
+	rec := &entryRecorder{}
+	now := time.Date(2022, 9, 20, 17, 0, 0, 0, time.UTC)
+	// 6/m, so only the first entry passes during the 2.5s flood.
+	w := &RateLimitWriter{Next: rec, Rate: 0.1, now: func() time.Time { return now }}
+
+	for i := 0; i < 25; i++ {
+		w.WriteEntry(&Entry{Level: zerolog.InfoLevel, Message: "flood"})
+		now = now.Add(100 * time.Millisecond)
+	}
+	w.Close()
+
+	assertMessages(t, []string{
+		"flood",
+		"… 11 entries suppressed by --max-rate",
+		"… 11 entries suppressed by --max-rate",
+		"… 2 entries suppressed by --max-rate",
+	}, rec.messages())
+}
+
+func TestRateLimitWriterIdle(t *testing.T) {
+	rec := &entryRecorder{}
+	w := &RateLimitWriter{Next: rec, Rate: 1, Idle: 10 * time.Millisecond}
+
+	for i := 0; i < 3; i++ {
+		w.WriteEntry(&Entry{Level: zerolog.InfoLevel, Message: "flood"})
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The marker is written once the flood stops, without another entry.
+	w.mu.Lock()
+	assertMessages(t, []string{
+		"flood",
+		"… 2 entries suppressed by --max-rate",
+	}, rec.messages())
+	w.mu.Unlock()
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"50", 50},
+		{"50/s", 50},
+		{"120/m", 2},
+		{"10/100ms", 100},
+	}
+	for _, tc := range tests {
+		got, err := ParseRate(tc.input)
+		if err != nil {
+			t.Errorf("%q: %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %v, got %v", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestSampleFilterByKey(t *testing.T) {
+	filter := &SampleFilter{Rate: 0.5, Key: "request_id", KeepErrors: true}
+	kept := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		for _, id := range []string{"r1", "r2", "r3", "r4", "r5", "r6"} {
+			e := &Entry{Level: zerolog.InfoLevel, Fields: []Field{{Key: "request_id", Value: id}}}
+			keep := filter.Keep(e)
+			if i > 0 && keep != kept[id] {
+				t.Errorf("request %s: sampled inconsistently", id)
+			}
+			kept[id] = keep
+		}
+	}
+	for id, keep := range kept {
+		if keep {
+			continue
+		}
+		if !filter.Keep(&Entry{Level: zerolog.ErrorLevel, Fields: []Field{{Key: "request_id", Value: id}}}) {
+			t.Errorf("request %s: want error entries to bypass sampling", id)
+		}
+	}
+}
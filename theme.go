@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jilleJr/relog/pkg/config"
+	"github.com/rs/zerolog"
+)
+
+type Theme struct {
+	Timestamp  *color.Color
+	Caller     *color.Color
+	Arrow      *color.Color
+	FieldName  *color.Color
+	ErrorValue *color.Color
+	// Dim is used for less important text, such as the raw input lines.
+	Dim *color.Color
+	// Match is used for the matches of the --regexp patterns.
+	Match *color.Color
+	// Property is used for values inserted into messages, such as the
+	// properties of message templates.
+	Property *color.Color
+	Levels   map[zerolog.Level]*color.Color
+}
+
+func (t Theme) LevelColor(level zerolog.Level) *color.Color {
+	if c, ok := t.Levels[level]; ok {
+		return c
+	}
+	return t.Levels[zerolog.NoLevel]
+}
+
+var themes = map[string]Theme{
+	"dark": {
+		Timestamp:  color.New(color.FgHiBlack),
+		Caller:     color.New(color.Bold),
+		Arrow:      color.New(color.FgCyan),
+		FieldName:  color.New(color.FgCyan),
+		ErrorValue: color.New(color.FgRed),
+		Dim:        color.New(color.FgHiBlack),
+		Match:      color.New(color.BgYellow, color.FgBlack),
+		Property:   color.New(color.FgHiCyan),
+		Levels: map[zerolog.Level]*color.Color{
+			zerolog.TraceLevel: color.New(color.FgMagenta),
+			zerolog.DebugLevel: color.New(color.FgBlue),
+			zerolog.InfoLevel:  color.New(color.FgGreen),
+			zerolog.WarnLevel:  color.New(color.FgYellow),
+			zerolog.ErrorLevel: color.New(color.FgRed, color.Bold),
+			zerolog.FatalLevel: color.New(color.FgRed, color.Bold),
+			zerolog.PanicLevel: color.New(color.FgRed, color.Bold),
+			zerolog.NoLevel:    color.New(color.Bold),
+		},
+	},
+	"light": {
+		Timestamp:  color.New(color.FgBlack),
+		Caller:     color.New(color.Bold),
+		Arrow:      color.New(color.FgBlue),
+		FieldName:  color.New(color.FgBlue),
+		ErrorValue: color.New(color.FgRed),
+		Dim:        color.New(color.Faint),
+		Match:      color.New(color.BgYellow, color.FgBlack),
+		Property:   color.New(color.FgBlue, color.Bold),
+		Levels: map[zerolog.Level]*color.Color{
+			zerolog.TraceLevel: color.New(color.FgMagenta),
+			zerolog.DebugLevel: color.New(color.FgCyan),
+			zerolog.InfoLevel:  color.New(color.FgGreen),
+			zerolog.WarnLevel:  color.New(color.FgMagenta, color.Bold),
+			zerolog.ErrorLevel: color.New(color.FgRed, color.Bold),
+			zerolog.FatalLevel: color.New(color.BgRed, color.FgWhite, color.Bold),
+			zerolog.PanicLevel: color.New(color.BgRed, color.FgWhite, color.Bold),
+			zerolog.NoLevel:    color.New(color.Bold),
+		},
+	},
+	"high-contrast": {
+		Timestamp:  color.New(color.FgHiWhite),
+		Caller:     color.New(color.FgHiWhite, color.Bold),
+		Arrow:      color.New(color.FgHiCyan, color.Bold),
+		FieldName:  color.New(color.FgHiCyan, color.Bold),
+		ErrorValue: color.New(color.FgHiRed, color.Bold),
+		Dim:        color.New(color.FgWhite),
+		Match:      color.New(color.BgHiYellow, color.FgBlack, color.Bold),
+		Property:   color.New(color.FgHiCyan, color.Bold),
+		Levels: map[zerolog.Level]*color.Color{
+			zerolog.TraceLevel: color.New(color.FgHiMagenta, color.Bold),
+			zerolog.DebugLevel: color.New(color.FgHiBlue, color.Bold),
+			zerolog.InfoLevel:  color.New(color.FgHiGreen, color.Bold),
+			zerolog.WarnLevel:  color.New(color.BgYellow, color.FgBlack, color.Bold),
+			zerolog.ErrorLevel: color.New(color.BgRed, color.FgHiWhite, color.Bold),
+			zerolog.FatalLevel: color.New(color.BgRed, color.FgHiWhite, color.Bold, color.Underline),
+			zerolog.PanicLevel: color.New(color.BgRed, color.FgHiWhite, color.Bold, color.Underline),
+			zerolog.NoLevel:    color.New(color.FgHiWhite, color.Bold),
+		},
+	},
+	// Avoids telling levels apart by red versus green alone.
+	"colorblind": {
+		Timestamp:  color.New(color.FgHiBlack),
+		Caller:     color.New(color.Bold),
+		Arrow:      color.New(color.FgCyan),
+		FieldName:  color.New(color.FgCyan),
+		ErrorValue: color.New(color.FgHiMagenta),
+		Dim:        color.New(color.FgHiBlack),
+		Match:      color.New(color.ReverseVideo),
+		Property:   color.New(color.FgHiCyan),
+		Levels: map[zerolog.Level]*color.Color{
+			zerolog.TraceLevel: color.New(color.FgWhite),
+			zerolog.DebugLevel: color.New(color.FgCyan),
+			zerolog.InfoLevel:  color.New(color.FgBlue),
+			zerolog.WarnLevel:  color.New(color.FgYellow, color.Bold),
+			zerolog.ErrorLevel: color.New(color.FgHiMagenta, color.Bold, color.Underline),
+			zerolog.FatalLevel: color.New(color.BgMagenta, color.FgHiWhite, color.Bold),
+			zerolog.PanicLevel: color.New(color.BgMagenta, color.FgHiWhite, color.Bold),
+			zerolog.NoLevel:    color.New(color.Bold),
+		},
+	},
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTheme returns one of the built-in themes with the overrides from the
+// config applied on top of it.
+func NewTheme(name string, colors config.Colors) (Theme, error) {
+	if name == "" {
+		name = "dark"
+	}
+	base, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, must be one of: %s", name, strings.Join(themeNames(), ", "))
+	}
+	theme := base
+	theme.Levels = make(map[zerolog.Level]*color.Color, len(base.Levels))
+	for level, c := range base.Levels {
+		theme.Levels[level] = c
+	}
+
+	overrides := []struct {
+		spec string
+		dst  **color.Color
+	}{
+		{colors.Timestamp, &theme.Timestamp},
+		{colors.Caller, &theme.Caller},
+		{colors.FieldName, &theme.FieldName},
+		{colors.Error, &theme.ErrorValue},
+		{colors.Match, &theme.Match},
+	}
+	for _, o := range overrides {
+		if o.spec == "" {
+			continue
+		}
+		c, err := ParseColor(o.spec)
+		if err != nil {
+			return Theme{}, err
+		}
+		*o.dst = c
+	}
+	for levelStr, spec := range colors.Levels {
+		level, err := zerolog.ParseLevel(strings.ToLower(levelStr))
+		if err != nil {
+			return Theme{}, fmt.Errorf("colors: %w", err)
+		}
+		c, err := ParseColor(spec)
+		if err != nil {
+			return Theme{}, fmt.Errorf("colors: level %q: %w", levelStr, err)
+		}
+		theme.Levels[level] = c
+	}
+	return theme, nil
+}
+
+var colorAttributes = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"blink":     color.BlinkSlow,
+	"reverse":   color.ReverseVideo,
+
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"gray":    color.FgHiBlack,
+	"grey":    color.FgHiBlack,
+
+	"hi-red":     color.FgHiRed,
+	"hi-green":   color.FgHiGreen,
+	"hi-yellow":  color.FgHiYellow,
+	"hi-blue":    color.FgHiBlue,
+	"hi-magenta": color.FgHiMagenta,
+	"hi-cyan":    color.FgHiCyan,
+	"hi-white":   color.FgHiWhite,
+
+	"bg-black":   color.BgBlack,
+	"bg-red":     color.BgRed,
+	"bg-green":   color.BgGreen,
+	"bg-yellow":  color.BgYellow,
+	"bg-blue":    color.BgBlue,
+	"bg-magenta": color.BgMagenta,
+	"bg-cyan":    color.BgCyan,
+	"bg-white":   color.BgWhite,
+}
+
+// ParseColor parses a space or comma separated list of color names and text
+// attributes, such as "red bold" or "bg-yellow,black".
+func ParseColor(spec string) (*color.Color, error) {
+	words := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty color")
+	}
+	attrs := make([]color.Attribute, 0, len(words))
+	for _, word := range words {
+		attr, ok := colorAttributes[strings.ToLower(word)]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", word)
+		}
+		attrs = append(attrs, attr)
+	}
+	return color.New(attrs...), nil
+}
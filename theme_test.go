@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jilleJr/relog/pkg/config"
+	"github.com/rs/zerolog"
+)
+
+func TestThemesDistinctLevels(t *testing.T) {
+	levels := []zerolog.Level{
+		zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel,
+		zerolog.WarnLevel, zerolog.ErrorLevel,
+	}
+	for _, name := range themeNames() {
+		theme := themes[name]
+		for i, a := range levels {
+			for _, b := range levels[i+1:] {
+				if theme.LevelColor(a).Equals(theme.LevelColor(b)) {
+					t.Errorf("%s: %s and %s have the same color", name, a, b)
+				}
+			}
+		}
+	}
+}
+
+func TestNewTheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		theme  string
+		colors config.Colors
+		level  zerolog.Level
+		want   *color.Color
+	}{
+		{
+			name:  "default is dark",
+			level: zerolog.WarnLevel,
+			want:  themes["dark"].Levels[zerolog.WarnLevel],
+		},
+		{
+			name:  "light",
+			theme: "light",
+			level: zerolog.WarnLevel,
+			want:  color.New(color.FgMagenta, color.Bold),
+		},
+		{
+			name:  "high-contrast",
+			theme: "high-contrast",
+			level: zerolog.ErrorLevel,
+			want:  color.New(color.BgRed, color.FgHiWhite, color.Bold),
+		},
+		{
+			name:  "colorblind",
+			theme: "colorblind",
+			level: zerolog.InfoLevel,
+			want:  color.New(color.FgBlue),
+		},
+		{
+			name:   "level override",
+			theme:  "light",
+			colors: config.Colors{Levels: map[string]string{"WARN": "hi-yellow bold"}},
+			level:  zerolog.WarnLevel,
+			want:   color.New(color.FgHiYellow, color.Bold),
+		},
+		{
+			name:   "other levels kept",
+			colors: config.Colors{Levels: map[string]string{"warn": "hi-yellow"}},
+			level:  zerolog.ErrorLevel,
+			want:   themes["dark"].Levels[zerolog.ErrorLevel],
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			theme, err := NewTheme(tc.theme, tc.colors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := theme.LevelColor(tc.level); !got.Equals(tc.want) {
+				t.Errorf("%s: want %v, got %v", tc.level, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewThemeOverrideNotShared(t *testing.T) {
+	if _, err := NewTheme("dark", config.Colors{Levels: map[string]string{"info": "red"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !themes["dark"].Levels[zerolog.InfoLevel].Equals(color.New(color.FgGreen)) {
+		t.Error("want the override to not change the built-in theme")
+	}
+}
+
+func TestNewThemeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		theme  string
+		colors config.Colors
+	}{
+		{name: "unknown theme", theme: "solarized"},
+		{name: "unknown level", colors: config.Colors{Levels: map[string]string{"loud": "red"}}},
+		{name: "unknown color", colors: config.Colors{Caller: "sparkly"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewTheme(tc.theme, tc.colors); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
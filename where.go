@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog"
+)
+
+// WhereExpr is a parsed --where filter expression, such as:
+//
+//	status >= 500 && path =~ "^/api" && !has(user)
+type WhereExpr interface {
+	Eval(e *Entry) bool
+}
+
+type whereAnd struct{ left, right WhereExpr }
+type whereOr struct{ left, right WhereExpr }
+type whereNot struct{ expr WhereExpr }
+type whereHas struct{ key string }
+type whereTruthy struct{ key string }
+type whereCompare struct {
+	key     string
+	op      string
+	operand string
+	regex   *regexp.Regexp
+	// level is the parsed operand of comparisons with the level.
+	level zerolog.Level
+}
+
+func (x whereAnd) Eval(e *Entry) bool { return x.left.Eval(e) && x.right.Eval(e) }
+func (x whereOr) Eval(e *Entry) bool  { return x.left.Eval(e) || x.right.Eval(e) }
+func (x whereNot) Eval(e *Entry) bool { return !x.expr.Eval(e) }
+
+func (x whereHas) Eval(e *Entry) bool {
+	_, ok := e.Lookup(x.key)
+	return ok
+}
+
+func (x whereTruthy) Eval(e *Entry) bool {
+	value, ok := e.Lookup(x.key)
+	if !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return true
+	}
+}
+
+func (x whereCompare) Eval(e *Entry) bool {
+	if x.key == "level" && x.regex == nil {
+		// NoLevel is above all other levels, but entries without a level
+		// are neither above nor below any level.
+		if e.Level == zerolog.NoLevel {
+			return x.op == "!="
+		}
+		return compareOrdered(int64(e.Level), int64(x.level), x.op)
+	}
+	value, ok := e.Lookup(x.key)
+	if !ok {
+		return false
+	}
+	if x.key == "time" {
+		t, ok := ParseFuzzyTime(x.operand)
+		if !ok {
+			return false
+		}
+		return compareOrdered(e.Time.UnixNano(), t.UnixNano(), x.op)
+	}
+	switch x.op {
+	case "=~":
+		return x.regex.MatchString(fieldValueString(value))
+	case "!~":
+		return !x.regex.MatchString(fieldValueString(value))
+	default:
+		return compareFieldValue(value, x.op, x.operand)
+	}
+}
+
+// ParseWhere parses a filter expression. It supports comparisons with
+// ==, !=, <, <=, >, >=, =~ (regex match) and !~, combined with &&, ||, !,
+// and parentheses, as well as has(field) and bare field names that check if
+// the field is set to a truthy value. Numeric levels are read with the scale.
+func ParseWhere(s string, scale LevelScale) (WhereExpr, error) {
+	tokens, err := lexWhere(s)
+	if err != nil {
+		return nil, err
+	}
+	p := whereParser{tokens: tokens, scale: scale}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != whereTokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return expr, nil
+}
+
+type whereTokenKind byte
+
+const (
+	whereTokenEOF whereTokenKind = iota
+	whereTokenWord
+	whereTokenString
+	whereTokenOp
+	whereTokenLParen
+	whereTokenRParen
+)
+
+type whereToken struct {
+	kind whereTokenKind
+	text string
+	pos  int
+}
+
+var whereOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "=", "<", ">", "!"}
+
+func lexWhere(s string) ([]whereToken, error) {
+	var tokens []whereToken
+	i := 0
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, whereToken{whereTokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, whereToken{whereTokenRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			var sb strings.Builder
+			for end < len(s) && rune(s[end]) != c {
+				if s[end] == '\\' && end+1 < len(s) {
+					end++
+				}
+				sb.WriteByte(s[end])
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, whereToken{whereTokenString, sb.String(), i})
+			i = end + 1
+		default:
+			if op := matchWhereOp(s[i:]); op != "" {
+				tokens = append(tokens, whereToken{whereTokenOp, op, i})
+				i += len(op)
+				continue
+			}
+			end := i
+			for end < len(s) && !isWhereWordEnd(s[end:]) {
+				end++
+			}
+			tokens = append(tokens, whereToken{whereTokenWord, s[i:end], i})
+			i = end
+		}
+	}
+	tokens = append(tokens, whereToken{whereTokenEOF, "end of expression", len(s)})
+	return tokens, nil
+}
+
+func matchWhereOp(s string) string {
+	for _, op := range whereOps {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isWhereWordEnd(s string) bool {
+	c := rune(s[0])
+	if unicode.IsSpace(c) || c == '(' || c == ')' || c == '"' || c == '\'' {
+		return true
+	}
+	return matchWhereOp(s) != ""
+}
+
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+	scale  LevelScale
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != whereTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *whereParser) parseOr() (WhereExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == whereTokenOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = whereOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (WhereExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == whereTokenOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = whereAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseUnary() (WhereExpr, error) {
+	if tok := p.peek(); tok.kind == whereTokenOp && tok.text == "!" {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return whereNot{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *whereParser) parsePrimary() (WhereExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case whereTokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != whereTokenRParen {
+			return nil, fmt.Errorf("expected ) at position %d, but got %q", closing.pos, closing.text)
+		}
+		return expr, nil
+	case whereTokenWord:
+		if tok.text == "has" && p.peek().kind == whereTokenLParen {
+			p.next()
+			key := p.next()
+			if key.kind != whereTokenWord && key.kind != whereTokenString {
+				return nil, fmt.Errorf("expected field name at position %d, but got %q", key.pos, key.text)
+			}
+			if closing := p.next(); closing.kind != whereTokenRParen {
+				return nil, fmt.Errorf("expected ) at position %d, but got %q", closing.pos, closing.text)
+			}
+			return whereHas{key.text}, nil
+		}
+		op := p.peek()
+		if op.kind != whereTokenOp || !isWhereCompareOp(op.text) {
+			return whereTruthy{tok.text}, nil
+		}
+		p.next()
+		operand := p.next()
+		if operand.kind != whereTokenWord && operand.kind != whereTokenString {
+			return nil, fmt.Errorf("expected value at position %d, but got %q", operand.pos, operand.text)
+		}
+		cmp := whereCompare{key: tok.text, op: op.text, operand: operand.text}
+		if cmp.op == "==" {
+			cmp.op = "="
+		}
+		if cmp.op == "=~" || cmp.op == "!~" {
+			regex, err := regexp.Compile(operand.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex at position %d: %w", operand.pos, err)
+			}
+			cmp.regex = regex
+		}
+		if cmp.key == "level" && cmp.regex == nil {
+			level, err := parseLevelFilter(cmp.operand, p.scale)
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, operand.pos)
+			}
+			cmp.level = level
+		}
+		return cmp, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+func isWhereCompareOp(op string) bool {
+	switch op {
+	case "==", "!=", "<=", ">=", "=~", "!~", "=", "<", ">":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestParseWhere(t *testing.T) {
+	e := &Entry{
+		Level:   zerolog.WarnLevel,
+		Message: "request failed",
+		Caller:  "server.go:12",
+		Fields: []Field{
+			{Key: "status", Value: int64(503)},
+			{Key: "path", Value: "/api/users"},
+			{Key: "duration", Value: "1.5s"},
+			{Key: "cached", Value: false},
+			{Key: "doc", Value: Object{{Key: "driver", Value: Object{{Key: "name", Value: "mongo"}}}}},
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `status >= 500 && path =~ "^/api" && !has(user)`, want: true},
+		{expr: `status >= 500 && has(user)`, want: false},
+		{expr: `status < 500 || level >= warn`, want: true},
+		{expr: `level == error`, want: false},
+		{expr: `duration > 1s`, want: true},
+		{expr: `cached`, want: false},
+		{expr: `!(cached || status == 200)`, want: true},
+		{expr: `doc.driver.name == mongo`, want: true},
+		{expr: `message =~ 'fail' && caller == "server.go:12"`, want: true},
+		{expr: `path != "/api/users"`, want: false},
+	}
+	for _, tc := range tests {
+		expr, err := ParseWhere(tc.expr, LevelScaleAuto)
+		if err != nil {
+			t.Errorf("parse %q: %s", tc.expr, err)
+			continue
+		}
+		if got := expr.Eval(e); got != tc.want {
+			t.Errorf("eval %q: want %t, got %t", tc.expr, tc.want, got)
+		}
+	}
+}
+
+func TestParseWhereNoLevel(t *testing.T) {
+	e := &Entry{Level: zerolog.NoLevel, Message: "plain text line"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `level >= error`, want: false},
+		{expr: `level > error`, want: false},
+		{expr: `level <= trace`, want: false},
+		{expr: `level == info`, want: false},
+		{expr: `level != info`, want: true},
+	}
+	for _, tc := range tests {
+		expr, err := ParseWhere(tc.expr, LevelScaleAuto)
+		if err != nil {
+			t.Errorf("parse %q: %s", tc.expr, err)
+			continue
+		}
+		if got := expr.Eval(e); got != tc.want {
+			t.Errorf("eval %q: want %t, got %t", tc.expr, tc.want, got)
+		}
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	for _, expr := range []string{
+		`status >=`,
+		`(status > 1`,
+		`path =~ "["`,
+		`level > loud`,
+		`level == default`,
+		`level != ""`,
+		`level >= 1`,
+		`status > 1 status`,
+	} {
+		if _, err := ParseWhere(expr, LevelScaleAuto); err == nil {
+			t.Errorf("expected error when parsing %q", expr)
+		}
+	}
+}